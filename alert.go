@@ -0,0 +1,199 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//AlertRule fires an alert when more than Threshold records of Level or
+//higher are written by a logger within Window, e.g. more than 10 errors
+//from "db" in a minute:
+//	log.AddAlert(log.AlertRule{
+//		Name:      "db-errors",
+//		Logger:    "db",
+//		Level:     log.ErrorLevel,
+//		Threshold: 10,
+//		Window:    time.Minute,
+//		Cooldown:  15 * time.Minute,
+//		OnAlert:   func(a log.Alert) { pager.Send(a.String()) },
+//	})
+type AlertRule struct {
+	Name string
+	//Logger is the name of the logger, e.g. "db", which also matches its
+	//children like "db/pool", or "" to match all loggers
+	Logger    string
+	Level     Level
+	Threshold int
+	//Window is the time in which records are counted, a minute if not positive
+	Window time.Duration
+	//Cooldown is the time after an alert before the rule can fire again
+	Cooldown time.Duration
+	//OnAlert is called when the rule fires, in a new goroutine
+	OnAlert func(Alert)
+	//WebhookURL is posted the alert as JSON when the rule fires, if not ""
+	WebhookURL string
+}
+
+//Alert describes why a rule fired
+type Alert struct {
+	Rule   string        `json:"rule"`
+	Logger string        `json:"logger"`
+	Level  Level         `json:"level"`
+	Count  int           `json:"count"`
+	Window time.Duration `json:"window_ns"`
+	Time   time.Time     `json:"time"`
+	//Message is the message of the record that made the rule fire, with
+	//secrets masked as in the encoded record
+	Message string `json:"message"`
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("alert %s: %d %s records from %s in %v, last: %s", a.Rule, a.Count, a.Level, a.Logger, a.Window, a.Message)
+}
+
+type alertState struct {
+	rule AlertRule
+	//escalation is set for states added with AddEscalation()
+	escalation *EscalationRule
+	//mutex protects times and quietUntil
+	mutex      sync.Mutex
+	times      []time.Time
+	quietUntil time.Time
+}
+
+//defaultAlertWindow is used for rules without a Window
+const defaultAlertWindow = time.Minute
+
+var (
+	alertsMutex sync.Mutex
+	alerts      []*alertState
+	alertCount  int32
+)
+
+//AddAlert adds a rule that is checked for each record written and returns
+//a func to remove it. A rule without OnAlert and WebhookURL is ignored.
+func AddAlert(rule AlertRule) (remove func()) {
+	if rule.OnAlert == nil && rule.WebhookURL == "" {
+		return func() {}
+	}
+	return addAlertState(&alertState{rule: rule})
+}
+
+func addAlertState(s *alertState) (remove func()) {
+	if s.rule.Threshold < 0 {
+		s.rule.Threshold = 0
+	}
+	//without a window no record would be counted with the previous ones
+	if s.rule.Window <= 0 {
+		s.rule.Window = defaultAlertWindow
+	}
+	s.rule.Logger = strings.Trim(s.rule.Logger, "/")
+	alertsMutex.Lock()
+	alerts = append(alerts, s)
+	atomic.StoreInt32(&alertCount, int32(len(alerts)))
+	alertsMutex.Unlock()
+	return func() {
+		alertsMutex.Lock()
+		defer alertsMutex.Unlock()
+		for i, a := range alerts {
+			if a == s {
+				alerts = append(alerts[:i:i], alerts[i+1:]...)
+				break
+			}
+		}
+		atomic.StoreInt32(&alertCount, int32(len(alerts)))
+	}
+} //AddAlert()
+
+//checkAlerts counts the written record in the matching rules
+func checkAlerts(l ILogger, r Record) {
+	if atomic.LoadInt32(&alertCount) == 0 {
+		return
+	}
+	alertsMutex.Lock()
+	states := alerts
+	alertsMutex.Unlock()
+	name := strings.Trim(l.Name(), "/")
+	for _, s := range states {
+		if r.Level < s.rule.Level {
+			continue
+		}
+		if s.rule.Logger != "" && name != s.rule.Logger && !strings.HasPrefix(name, s.rule.Logger+"/") {
+			continue
+		}
+		if s.escalation != nil && !s.escalation.matches(r) {
+			continue
+		}
+		a, ok := s.add(name, r)
+		if !ok {
+			continue
+		}
+		if ll, ok := l.(*logger); ok {
+			a.Message = redactSecrets(ll.encoder, a.Message)
+		}
+		if s.escalation != nil {
+			s.escalation.escalate(l, r, a)
+		}
+		if s.rule.OnAlert != nil || s.rule.WebhookURL != "" {
+			go s.fire(a)
+		}
+	}
+} //checkAlerts()
+
+//add counts the record and returns an alert when the rule fires
+func (s *alertState) add(name string, r Record) (Alert, bool) {
+	t := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	//drop times outside the window, there are at most threshold of them
+	keep := s.times[:0]
+	for _, rt := range s.times {
+		if t.Sub(rt) < s.rule.Window {
+			keep = append(keep, rt)
+		}
+	}
+	s.times = append(keep, t)
+	if len(s.times) <= s.rule.Threshold {
+		return Alert{}, false
+	}
+	count := len(s.times)
+	s.times = s.times[:0]
+	if t.Before(s.quietUntil) {
+		return Alert{}, false
+	}
+	s.quietUntil = t.Add(s.rule.Cooldown)
+	return Alert{
+		Rule:    s.rule.Name,
+		Logger:  "/" + name,
+		Level:   r.Level,
+		Count:   count,
+		Window:  s.rule.Window,
+		Time:    t,
+		Message: r.Message,
+	}, true
+} //alertState.add()
+
+func (s *alertState) fire(a Alert) {
+	if s.rule.OnAlert != nil {
+		s.rule.OnAlert(a)
+	}
+	if s.rule.WebhookURL != "" {
+		body, _ := json.Marshal(a)
+		client := http.Client{Timeout: 10 * time.Second}
+		res, err := client.Post(s.rule.WebhookURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			handleError(fmt.Errorf("alert %s webhook failed: %v", a.Rule, err))
+			return
+		}
+		res.Body.Close()
+		if res.StatusCode/100 != 2 {
+			handleError(fmt.Errorf("alert %s webhook failed: %s", a.Rule, res.Status))
+		}
+	}
+} //alertState.fire()
@@ -0,0 +1,24 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEscalationWithoutWindow(t *testing.T) {
+	remove := AddEscalation(EscalationRule{Name: "retries", Logger: "xescalation", Threshold: 2})
+	defer remove()
+	buf := &bytes.Buffer{}
+	l := Top().Temp("xescalation").WithWriter(buf).WithEncoder(NewJSONEncoder()).WithGroup("db")
+	for i := 0; i < 3; i++ {
+		l.Warnf("retry %d", i)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "escalated retries: 3 warnings in 1m0s") {
+		t.Fatalf("not escalated: %s", out)
+	}
+	if !strings.Contains(out, `"db":{"escalation":"retries","warnings":3}`) {
+		t.Fatalf("escalation fields not in group: %s", out)
+	}
+}
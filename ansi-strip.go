@@ -0,0 +1,92 @@
+package log
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//ansiPattern matches CSI sequences like colors, OSC sequences like
+//hyperlinks and the other two character escape sequences
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+//StripANSIText removes ANSI escape sequences from s
+func StripANSIText(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+//StripANSI wraps an encoder to remove ANSI escape sequences from the
+//message and string data values before encoding, e.g. color codes in
+//output of a subprocess that is logged line by line. Escape sequences
+//written by the encoder itself, like level colors, are kept.
+//The logger already does this for writers other than a terminal
+//unless disabled with SetStripANSI(false), so this is only needed
+//to strip records that are written to a terminal.
+func StripANSI(e IEncoder) IEncoder {
+	return ansiStripper{encoder: e}
+}
+
+var stripANSIOff int32
+
+//SetStripANSI sets whether records are stripped of ANSI escape sequences
+//before encoding when written to files and network writers (default true)
+//Records written to stdout or stderr are never stripped when that is a
+//terminal, unless the encoder is wrapped with StripANSI().
+func SetStripANSI(on bool) {
+	v := int32(1)
+	if on {
+		v = 0
+	}
+	atomic.StoreInt32(&stripANSIOff, v)
+}
+
+type ansiStripper struct {
+	encoder IEncoder
+}
+
+func (s ansiStripper) Encode(l ILogger, r Record) []byte {
+	return s.encoder.Encode(l, stripANSIRecord(r))
+}
+
+//stripANSIRecord returns the record with ANSI escape sequences removed
+//from the message and data strings
+func stripANSIRecord(r Record) Record {
+	r.Message = StripANSIText(r.Message)
+	return r.withStage(func(data map[string]interface{}) {
+		for n, v := range data {
+			if s, ok := v.(string); ok {
+				data[n] = StripANSIText(s)
+			}
+		}
+	})
+}
+
+var (
+	terminalOnce sync.Once
+	terminals    map[io.Writer]bool
+)
+
+//stripANSIFor is true when records written to w must be stripped
+func stripANSIFor(w io.Writer) bool {
+	if atomic.LoadInt32(&stripANSIOff) == 1 {
+		return false
+	}
+	terminalOnce.Do(func() {
+		terminals = map[io.Writer]bool{}
+		for _, f := range []*os.File{os.Stdout, os.Stderr} {
+			if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+				terminals[f] = true
+			}
+		}
+	})
+	if f, ok := w.(*os.File); ok {
+		return !terminals[f]
+	}
+	return true
+} //stripANSIFor()
@@ -0,0 +1,155 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sync"
+)
+
+//IRecordQueue is implemented by writers that encode records themselves,
+//e.g. on other goroutines, the logger passes each record to QueueRecord
+//without encoding it
+type IRecordQueue interface {
+	QueueRecord(l ILogger, r Record) error
+}
+
+//errAsyncClosed is returned for records queued after Close()
+var errAsyncClosed = errors.New("async writer closed")
+
+//NewAsyncWriter returns a writer that encodes records on workers goroutines
+//and writes them to w, so that encoding, e.g. JSON, is not limited to one
+//core or done by the goroutine that logs. Records are sharded by logger
+//name, so the records of one logger are written in the order logged, but
+//records of different loggers may be reordered. Each worker queues up to
+//queueSize records after which logging blocks until there is space.
+//Records are encoded with e, or with the encoder of the logger when nil.
+//Flush() waits until all queued records are written and then flushes w,
+//and Close() also stops the workers.
+//	log.Top().SetWriter(log.NewAsyncWriter(os.Stdout, nil, 4, 1000))
+func NewAsyncWriter(w io.Writer, e IEncoder, workers, queueSize int) IBufferedWriter {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	aw := &asyncWriter{w: w, encoder: e}
+	for i := 0; i < workers; i++ {
+		q := make(chan asyncItem, queueSize)
+		aw.queues = append(aw.queues, q)
+		aw.workers.Add(1)
+		go aw.work(q)
+	}
+	return aw
+}
+
+type asyncWriter struct {
+	w       io.Writer
+	encoder IEncoder
+	queues  []chan asyncItem
+	workers sync.WaitGroup
+	//writeMutex serialises writes to w from the workers
+	writeMutex sync.Mutex
+	//closeMutex is held for reading while queueing so that
+	//Close() does not close the queues while they are used
+	closeMutex sync.RWMutex
+	closed     bool
+}
+
+//asyncItem is a record to write or else a flush marker
+type asyncItem struct {
+	logger  ILogger
+	record  Record
+	flushed chan struct{}
+}
+
+func (aw *asyncWriter) QueueRecord(l ILogger, r Record) error {
+	//the record is encoded after the caller returns, so keep nothing that
+	//the caller reuses: fields of a pooled Builder or an Ephemeral logger
+	r.Fields = append([]Field(nil), r.Fields...)
+	if ll, ok := l.(*logger); ok {
+		l = ll.retained()
+	}
+	h := fnv.New32a()
+	h.Write([]byte(l.Name()))
+	aw.closeMutex.RLock()
+	defer aw.closeMutex.RUnlock()
+	if aw.closed {
+		return errAsyncClosed
+	}
+	aw.queues[h.Sum32()%uint32(len(aw.queues))] <- asyncItem{logger: l, record: r}
+	return nil
+}
+
+//Write writes output from other sources than a logger directly to w
+func (aw *asyncWriter) Write(p []byte) (int, error) {
+	aw.writeMutex.Lock()
+	defer aw.writeMutex.Unlock()
+	return aw.w.Write(p)
+}
+
+func (aw *asyncWriter) work(q chan asyncItem) {
+	defer aw.workers.Done()
+	for item := range q {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		e := aw.encoder
+		if e == nil {
+			if ll, ok := item.logger.(*logger); ok {
+				e = ll.encoder
+			}
+		}
+		if e == nil {
+			continue
+		}
+		encoded, err := encode(e, item.logger, item.record)
+		if err != nil {
+			handleError(err)
+			continue
+		}
+		aw.writeMutex.Lock()
+		_, err = timedWrite(aw.w, item.logger, item.record, encoded)
+		aw.writeMutex.Unlock()
+		if err != nil {
+			handleError(fmt.Errorf("write to %s failed: %v", writerIdentity(aw.w), err))
+		}
+	}
+} //asyncWriter.work()
+
+func (aw *asyncWriter) Flush() error {
+	aw.closeMutex.RLock()
+	if !aw.closed {
+		markers := make([]chan struct{}, len(aw.queues))
+		for i, q := range aw.queues {
+			markers[i] = make(chan struct{})
+			q <- asyncItem{flushed: markers[i]}
+		}
+		for _, m := range markers {
+			<-m
+		}
+	}
+	aw.closeMutex.RUnlock()
+	aw.writeMutex.Lock()
+	defer aw.writeMutex.Unlock()
+	if f, ok := aw.w.(IFlusher); ok {
+		return f.Flush()
+	}
+	return nil
+} //asyncWriter.Flush()
+
+func (aw *asyncWriter) Close() error {
+	aw.closeMutex.Lock()
+	if !aw.closed {
+		aw.closed = true
+		for _, q := range aw.queues {
+			close(q)
+		}
+	}
+	aw.closeMutex.Unlock()
+	aw.workers.Wait()
+	return aw.Flush()
+} //asyncWriter.Close()
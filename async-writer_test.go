@@ -0,0 +1,59 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//lockedBuffer is a bytes.Buffer that can be written concurrently
+type lockedBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestAsyncWriterKeepsPooledFields(t *testing.T) {
+	out := &lockedBuffer{}
+	aw := NewAsyncWriter(out, NewJSONEncoder(), 2, 1000)
+	defer aw.Close()
+	l := Top().Temp("asynctest").WithWriter(aw).WithLevel(InfoLevel)
+
+	const goroutines, records = 4, 2000
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				e := l.Ephemeral(Field{Name: "req", Value: "r1"})
+				e.InfoEvent().Int("n", i).Msg("x")
+				e.Release()
+			}
+		}()
+	}
+	wg.Wait()
+	aw.Flush()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != goroutines*records {
+		t.Fatalf("got %d records, want %d", len(lines), goroutines*records)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"n":`) || !strings.Contains(line, `"req":"r1"`) || !strings.Contains(line, `"logger":"/asynctest"`) {
+			t.Fatalf("record lost fields: %s", line)
+		}
+	}
+}
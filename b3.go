@@ -0,0 +1,135 @@
+package log
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//Zipkin B3 headers, either the single header or the multi headers
+const (
+	B3Header             = "b3"
+	B3TraceIDHeader      = "X-B3-TraceId"
+	B3SpanIDHeader       = "X-B3-SpanId"
+	B3ParentSpanIDHeader = "X-B3-ParentSpanId"
+	B3SampledHeader      = "X-B3-Sampled"
+	B3FlagsHeader        = "X-B3-Flags"
+)
+
+//ParseB3 parses a B3 single header value
+//"<trace-id>-<span-id>[-<sampled>[-<parent-span-id>]]" where the trace id
+//is 16 or 32 hex characters and sampled is "1", "0" or "d" (debug)
+func ParseB3(s string) (TraceContext, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return TraceContext{}, fmt.Errorf("invalid b3 %q", s)
+	}
+	sampled, parent := "", ""
+	if len(parts) > 2 {
+		sampled = parts[2]
+	}
+	if len(parts) > 3 {
+		parent = parts[3]
+	}
+	tc, err := b3TraceContext(parts[0], parts[1], parent, sampled, "")
+	if err != nil {
+		return tc, fmt.Errorf("invalid b3 %q: %v", s, err)
+	}
+	return tc, nil
+} //ParseB3()
+
+//extractB3 gets the trace context from the B3 single header
+//or else from the B3 multi headers
+func extractB3(h http.Header) (TraceContext, bool) {
+	if s := h.Get(B3Header); s != "" {
+		tc, err := ParseB3(s)
+		return tc, err == nil
+	}
+	if h.Get(B3TraceIDHeader) == "" {
+		return TraceContext{}, false
+	}
+	tc, err := b3TraceContext(h.Get(B3TraceIDHeader), h.Get(B3SpanIDHeader), h.Get(B3ParentSpanIDHeader), h.Get(B3SampledHeader), h.Get(B3FlagsHeader))
+	return tc, err == nil
+} //extractB3()
+
+func b3TraceContext(traceID, spanID, parentSpanID, sampled, flags string) (TraceContext, error) {
+	tc := TraceContext{}
+	if len(traceID) != 16 && len(traceID) != 32 {
+		return tc, fmt.Errorf("invalid trace id %q", traceID)
+	}
+	if len(spanID) != 16 {
+		return tc, fmt.Errorf("invalid span id %q", spanID)
+	}
+	traceID = strings.ToLower(traceID)
+	spanID = strings.ToLower(spanID)
+	if !isLowerHex(traceID) || !isLowerHex(spanID) {
+		return tc, fmt.Errorf("invalid ids %s-%s", traceID, spanID)
+	}
+	//64 bit trace ids are the lower half of the 128 bit id
+	hex.Decode(tc.TraceID[16-len(traceID)/2:], []byte(traceID))
+	hex.Decode(tc.SpanID[:], []byte(spanID))
+	if !tc.IsValid() {
+		return tc, fmt.Errorf("invalid ids %s-%s", traceID, spanID)
+	}
+	if parentSpanID != "" {
+		parentSpanID = strings.ToLower(parentSpanID)
+		if len(parentSpanID) != 16 || !isLowerHex(parentSpanID) {
+			return tc, fmt.Errorf("invalid parent span id %q", parentSpanID)
+		}
+		hex.Decode(tc.ParentSpanID[:], []byte(parentSpanID))
+	}
+	switch strings.ToLower(sampled) {
+	case "d":
+		tc.Flags = 0x01
+		tc.Debug = true
+	case "1", "true":
+		tc.Flags = 0x01
+	case "", "0", "false":
+	default:
+		return tc, fmt.Errorf("invalid sampling state %q", sampled)
+	}
+	if flags == "1" {
+		tc.Flags = 0x01
+		tc.Debug = true
+	}
+	return tc, nil
+} //b3TraceContext()
+
+//B3 formats the B3 single header value
+//"<trace-id>-<span-id>-<sampled>[-<parent-span-id>]"
+func (tc TraceContext) B3() string {
+	b3 := tc.TraceIDHex() + "-" + tc.SpanIDHex() + "-" + tc.b3Sampled()
+	if tc.ParentSpanID != [8]byte{} {
+		b3 += "-" + hex.EncodeToString(tc.ParentSpanID[:])
+	}
+	return b3
+}
+
+//b3Sampled is the B3 sampling state "d" (debug), "1" or "0"
+func (tc TraceContext) b3Sampled() string {
+	switch {
+	case tc.Debug:
+		return "d"
+	case tc.Sampled():
+		return "1"
+	default:
+		return "0"
+	}
+}
+
+//injectB3Multi sets the B3 multi headers
+func (tc TraceContext) injectB3Multi(h http.Header) {
+	h.Set(B3TraceIDHeader, tc.TraceIDHex())
+	h.Set(B3SpanIDHeader, tc.SpanIDHex())
+	if tc.ParentSpanID != [8]byte{} {
+		h.Set(B3ParentSpanIDHeader, hex.EncodeToString(tc.ParentSpanID[:]))
+	}
+	if tc.Debug {
+		//debug implies sampled, so X-B3-Sampled is not sent with it
+		h.Set(B3FlagsHeader, "1")
+	} else {
+		h.Set(B3SampledHeader, tc.b3Sampled())
+	}
+}
@@ -0,0 +1,86 @@
+package log
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseB3(t *testing.T) {
+	tests := []struct {
+		in      string
+		traceID string
+		parent  string
+		sampled bool
+		debug   bool
+		wantErr bool
+	}{
+		{in: testTraceID + "-" + testSpanID, traceID: testTraceID},
+		{in: testTraceID + "-" + testSpanID + "-1", traceID: testTraceID, sampled: true},
+		{in: testTraceID + "-" + testSpanID + "-d", traceID: testTraceID, sampled: true, debug: true},
+		{in: testTraceID + "-" + testSpanID + "-0-" + testParent, traceID: testTraceID, parent: testParent},
+		{in: "a3ce929d0e0e4736-" + testSpanID + "-1", traceID: "0000000000000000a3ce929d0e0e4736", sampled: true},
+		{in: "4BF92F3577B34DA6A3CE929D0E0E4736-" + testSpanID, traceID: testTraceID},
+		{in: testTraceID + "-" + testSpanID + "-x", wantErr: true},
+		{in: testTraceID + "-" + testSpanID + "-1-zz", wantErr: true},
+		{in: testTraceID + "-" + testSpanID + "-1-" + testParent + "-x", wantErr: true},
+		{in: testTraceID, wantErr: true},
+		{in: "0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			tc, err := ParseB3(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if err != nil {
+				return
+			}
+			parent := ""
+			if tc.ParentSpanID != [8]byte{} {
+				parent = tc.B3()[len(tc.B3())-16:]
+			}
+			if tc.TraceIDHex() != tt.traceID || tc.SpanIDHex() != testSpanID || parent != tt.parent || tc.Sampled() != tt.sampled || tc.Debug != tt.debug {
+				t.Fatalf("parsed %+v", tc)
+			}
+		})
+	}
+}
+
+func TestExtractTraceContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string][]string
+		want    string
+		ok      bool
+	}{
+		{"w3c", map[string][]string{"Traceparent": {"00-" + testTraceID + "-" + testSpanID + "-01"}}, testSpanID, true},
+		{"multiple traceparent", map[string][]string{"Traceparent": {"00-" + testTraceID + "-" + testSpanID + "-01", "00-" + testTraceID + "-" + testParent + "-01"}}, "", false},
+		{"b3 single", map[string][]string{"B3": {testTraceID + "-" + testSpanID + "-1"}}, testSpanID, true},
+		{"b3 multi", map[string][]string{"X-B3-Traceid": {testTraceID}, "X-B3-Spanid": {testSpanID}, "X-B3-Flags": {"1"}}, testSpanID, true},
+		{"b3 multi without span", map[string][]string{"X-B3-Traceid": {testTraceID}}, "", false},
+		{"none", map[string][]string{}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, ok := ExtractTraceContext(http.Header(tt.headers))
+			if ok != tt.ok || (ok && tc.SpanIDHex() != tt.want) {
+				t.Fatalf("ExtractTraceContext() = %+v, %v", tc, ok)
+			}
+		})
+	}
+}
+
+func TestTraceContextInjectRoundTrip(t *testing.T) {
+	in, _ := ParseB3(testTraceID + "-" + testSpanID + "-d-" + testParent)
+	for _, p := range []Propagation{PropagateW3C, PropagateB3Single, PropagateB3Multi} {
+		h := http.Header{}
+		in.Inject(h, p)
+		out, ok := ExtractTraceContext(h)
+		if !ok || out.TraceID != in.TraceID || out.SpanID != in.SpanID || !out.Sampled() {
+			t.Fatalf("propagation %d: %+v from %v", p, out, h)
+		}
+		if p != PropagateW3C && (out.ParentSpanID != in.ParentSpanID || !out.Debug) {
+			t.Fatalf("propagation %d lost parent or debug: %+v from %v", p, out, h)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+//IFlusher is implemented by writers that buffer output
+//the logger flushes its writer after writing a panic or fatal record
+type IFlusher interface {
+	Flush() error
+}
+
+//IBufferedWriter buffers output and flushes it at least every interval
+type IBufferedWriter interface {
+	io.WriteCloser
+	IFlusher
+}
+
+//NewBufferedWriter returns a writer that buffers up to size bytes before
+//writing to w, and flushes at least every interval so that output is not
+//delayed for long. Close() flushes and stops the background flushing.
+func NewBufferedWriter(w io.Writer, size int, interval time.Duration) IBufferedWriter {
+	bw := &bufferedWriter{
+		buf:  bufio.NewWriterSize(w, size),
+		stop: make(chan struct{}),
+	}
+	if interval > 0 {
+		go bw.flushEvery(interval)
+	}
+	return bw
+}
+
+//SetBuffered wraps the writer of the top logger in a buffered writer
+//and sets it on all loggers, so that many small writes to e.g. stderr
+//become fewer larger writes. See NewBufferedWriter().
+func SetBuffered(size int, interval time.Duration) IBufferedWriter {
+	tl := top.(*logger)
+	bw := NewBufferedWriter(tl.writer, size, interval)
+	top.SetWriter(bw)
+	return bw
+}
+
+//bufferedWriter implements IBufferedWriter
+type bufferedWriter struct {
+	mutex     sync.Mutex
+	buf       *bufio.Writer
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+func (bw *bufferedWriter) Write(p []byte) (int, error) {
+	bw.mutex.Lock()
+	defer bw.mutex.Unlock()
+	return bw.buf.Write(p)
+}
+
+func (bw *bufferedWriter) Flush() error {
+	bw.mutex.Lock()
+	defer bw.mutex.Unlock()
+	return bw.buf.Flush()
+}
+
+func (bw *bufferedWriter) Close() error {
+	bw.closeOnce.Do(func() {
+		close(bw.stop)
+	})
+	return bw.Flush()
+}
+
+func (bw *bufferedWriter) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-bw.stop:
+			return
+		case <-ticker.C:
+			if err := bw.Flush(); err != nil {
+				handleError(fmt.Errorf("buffered writer flush failed: %v", err))
+			}
+		}
+	}
+} //bufferedWriter.flushEvery()
+
+//Flush flushes the writers of all loggers that buffer output
+func Flush() {
+	flushed := map[IFlusher]bool{}
+	top.(*logger).walk(func(l *logger) {
+		if f, ok := l.writer.(IFlusher); ok && !flushed[f] {
+			flushed[f] = true
+			f.Flush()
+		}
+	})
+} //Flush()
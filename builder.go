@@ -0,0 +1,139 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+//Builder adds fields to a record before it is logged with Msg() or Msgf(), e.g.
+//	l.InfoEvent().Str("user", u).Int("count", n).Dur("took", d).Msg("done")
+//When the level is not enabled the logger returns a nil builder and all
+//methods do nothing, so nothing is allocated for disabled levels.
+//A builder must not be used after Msg() or Msgf().
+type Builder struct {
+	l      *logger
+	level  Level
+	fields []Field
+}
+
+var builderPool = sync.Pool{
+	New: func() interface{} {
+		return &Builder{fields: make([]Field, 0, 8)}
+	},
+}
+
+//enabled is true when records at the level will be written
+func (l *logger) enabled(level Level) bool {
+	return l.encoder != nil && l.writer != nil && level >= l.level
+}
+
+func (l *logger) LogEvent(level Level) *Builder {
+	if !l.enabled(level) {
+		return nil
+	}
+	b := builderPool.Get().(*Builder)
+	b.l = l
+	b.level = level
+	return b
+}
+
+func (l *logger) TraceEvent() *Builder { return l.LogEvent(TraceLevel) }
+func (l *logger) DebugEvent() *Builder { return l.LogEvent(DebugLevel) }
+func (l *logger) InfoEvent() *Builder  { return l.LogEvent(InfoLevel) }
+func (l *logger) WarnEvent() *Builder  { return l.LogEvent(WarnLevel) }
+func (l *logger) ErrorEvent() *Builder { return l.LogEvent(ErrorLevel) }
+func (l *logger) FatalEvent() *Builder { return l.LogEvent(FatalLevel) }
+
+//Str adds a string field
+func (b *Builder) Str(name, value string) *Builder {
+	return b.Any(name, value)
+}
+
+//Int adds an int field
+func (b *Builder) Int(name string, value int) *Builder {
+	return b.Any(name, value)
+}
+
+//Int64 adds an int64 field
+func (b *Builder) Int64(name string, value int64) *Builder {
+	return b.Any(name, value)
+}
+
+//Uint64 adds an uint64 field
+func (b *Builder) Uint64(name string, value uint64) *Builder {
+	return b.Any(name, value)
+}
+
+//Float64 adds a float64 field
+func (b *Builder) Float64(name string, value float64) *Builder {
+	return b.Any(name, value)
+}
+
+//Bool adds a bool field
+func (b *Builder) Bool(name string, value bool) *Builder {
+	return b.Any(name, value)
+}
+
+//Dur adds a duration field
+func (b *Builder) Dur(name string, value time.Duration) *Builder {
+	return b.Any(name, value)
+}
+
+//Time adds a time field
+func (b *Builder) Time(name string, value time.Time) *Builder {
+	return b.Any(name, value)
+}
+
+//Err adds the error as field "error", or nothing if err is nil
+func (b *Builder) Err(err error) *Builder {
+	if err == nil {
+		return b
+	}
+	return b.Any("error", err)
+}
+
+//Any adds a field of any type
+func (b *Builder) Any(name string, value interface{}) *Builder {
+	if b == nil {
+		return b
+	}
+	b.fields = append(b.fields, Field{Name: name, Value: value})
+	return b
+}
+
+//Msg logs the record with all fields added
+func (b *Builder) Msg(msg string) {
+	if b == nil {
+		return
+	}
+	l, level := b.l, b.level
+	l.log(0, level, msg, b.fields...)
+	b.release()
+	if level == FatalLevel {
+		l.fatal(msg, nil)
+	}
+}
+
+//Msgf logs the record with all fields added
+func (b *Builder) Msgf(format string, args ...interface{}) {
+	if b == nil {
+		return
+	}
+	l, level, msg := b.l, b.level, fmt.Sprintf(format, args...)
+	l.log(0, level, msg, b.fields...)
+	b.release()
+	if level == FatalLevel {
+		l.fatal(msg, nil)
+	}
+}
+
+//release returns the builder to the pool for reuse
+func (b *Builder) release() {
+	b.l = nil
+	for i := range b.fields {
+		b.fields[i] = Field{}
+	}
+	b.fields = b.fields[:0]
+	builderPool.Put(b)
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"fmt"
+	"path"
+)
+
+//FileFormat selects how much of the source file path is written
+type FileFormat int
+
+const (
+	//FullFile writes Caller.File as is (after any trimming, see SetTrimPrefixes())
+	FullFile FileFormat = iota
+	//ShortFile writes the file path relative to the module root of its
+	//package, e.g. "pkg/server/handler.go"
+	ShortFile
+	//BaseFile writes only the file name, e.g. "handler.go"
+	BaseFile
+)
+
+//CallerFormat controls how the file and line of the caller are written
+type CallerFormat struct {
+	File FileFormat
+	//PadLine writes the line padded in brackets, e.g. "handler.go(   42)",
+	//else after a colon, e.g. "handler.go:42"
+	PadLine bool
+	//ZeroPad pads the line with zeros rather than spaces when PadLine is
+	//set, e.g. "handler.go(00042)"
+	ZeroPad bool
+	//Link is a URL template written instead of the file and line so that
+	//clicking it opens the code, e.g. VSCodeLink or GitHubLink("org/repo"),
+	//with placeholders:
+	//	{path}   full source file path, starting with "/"
+	//	{rel}    file path relative to the module root
+	//	{line}   line number
+	//	{commit} source commit, see SetSourceCommit()
+	Link string
+	//Hyperlink writes the file and line as text that links to the Link URL
+	//in terminals that support hyperlinks (OSC 8), rather than writing the
+	//URL, when escape sequences are enabled (see ColorEnabled())
+	Hyperlink bool
+}
+
+//Format writes the caller file and line, or the link
+func (cf CallerFormat) Format(c Caller) string {
+	if cf.Link == "" {
+		return cf.text(c)
+	}
+	if cf.Hyperlink {
+		return hyperlink(cf.URL(c), cf.text(c))
+	}
+	return cf.URL(c)
+}
+
+//text writes the caller file and line
+func (cf CallerFormat) text(c Caller) string {
+	file := c.File
+	switch cf.File {
+	case ShortFile:
+		file = relativeSourcePath(c)
+	case BaseFile:
+		file = path.Base(file)
+	}
+	if cf.PadLine && cf.ZeroPad {
+		return fmt.Sprintf("%s(%05d)", file, c.Line)
+	}
+	if cf.PadLine {
+		return fmt.Sprintf("%s(%5d)", file, c.Line)
+	}
+	return fmt.Sprintf("%s:%d", file, c.Line)
+} //CallerFormat.text()
@@ -0,0 +1,36 @@
+package log
+
+import (
+	"fmt"
+	"path"
+	"testing"
+)
+
+func TestCallerFormat(t *testing.T) {
+	c := GetCaller(2) //this func
+	inPackage := c
+	inPackage.Path = path.Join(path.Dir(c.Path), "logtest", "tb-writer.go")
+	inPackage.Line = 10
+	noPath := Caller{File: "/src/app/pkg/server/handler.go", Line: 42}
+	tests := []struct {
+		name   string
+		caller Caller
+		format CallerFormat
+		want   string
+	}{
+		{"full", c, CallerFormat{File: FullFile}, fmt.Sprintf("%s:%d", c.File, c.Line)},
+		{"short", c, CallerFormat{File: ShortFile}, fmt.Sprintf("caller-format_test.go:%d", c.Line)},
+		{"base", c, CallerFormat{File: BaseFile}, fmt.Sprintf("caller-format_test.go:%d", c.Line)},
+		{"padded", c, CallerFormat{File: BaseFile, PadLine: true}, fmt.Sprintf("caller-format_test.go(%5d)", c.Line)},
+		{"zero padded", c, CallerFormat{File: BaseFile, PadLine: true, ZeroPad: true}, fmt.Sprintf("caller-format_test.go(%05d)", c.Line)},
+		{"short in package", inPackage, CallerFormat{File: ShortFile}, "logtest/tb-writer.go:10"},
+		{"short without path", noPath, CallerFormat{File: ShortFile, PadLine: true}, "src/app/pkg/server/handler.go(   42)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.Format(tt.caller); got != tt.want {
+				t.Fatalf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,6 +12,10 @@ type Caller struct {
 	Function string
 	File     string
 	Line     int
+	//Path is the full source file path before trimming, "" if not known
+	Path string
+	//PC identifies the call site, 0 if not known
+	PC uintptr
 }
 
 //GetCaller skipping N levels in call stack
@@ -26,7 +30,7 @@ func GetCaller(skip int) Caller {
 	{
 		//get call stack details
 		//note: pc array size determines max depth call stack retrieved
-		pc := make([]uintptr, 10)
+		pc := make([]uintptr, 32)
 		//n is nr of items retrieved
 		n := runtime.Callers(0, pc)
 		//fmt.Printf("Got n=%d frames:\n", n)
@@ -40,6 +44,7 @@ func GetCaller(skip int) Caller {
 		//		Frame[4] = logger.Debug()/Info()/...
 		//		Frame[5] = the one we want!!!
 		//we are only interested in [5], so cannot retrieve if stack is shallower
+		//wrappers that call ILogger.LogDepth() skip more frames to get to their caller
 
 		//print the whole stack for debugging this func:
 		// if true {
@@ -55,10 +60,16 @@ func GetCaller(skip int) Caller {
 		// 	}
 		// }
 
-		if n >= skip {
-			pc = pc[skip : skip+1]
-			frames := runtime.CallersFrames(pc)
-			frame, _ := frames.Next()
+		//iterate over frames rather than indexing pc, because one pc
+		//may expand to more than one frame when functions are inlined
+		frames := runtime.CallersFrames(pc[:n])
+		frame, more := frames.Next()
+		i := 0
+		for i < skip && more {
+			frame, more = frames.Next()
+			i++
+		}
+		if i == skip {
 
 			//function is "<package>.<func>" and <package> is path notation that may contain more '.'
 			//get basename of package then split on '.'
@@ -85,8 +96,10 @@ func GetCaller(skip int) Caller {
 			// 	caller.Package = ""
 			// 	caller.Function = frame.Function
 			// }
-			caller.File = frame.File
+			caller.File = trimSourcePath(frame.File, frame.Function)
+			caller.Path = frame.File
 			caller.Line = frame.Line
+			caller.PC = frame.PC
 		} //if stack is deep enough
 	} //scope
 	return caller
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//onceSites has the call sites that already logged with Once()
+var onceSites sync.Map
+
+func (l *logger) Once() ILogger {
+	sub := l.anon(l.group)
+	sub.gate = func(r *Record) bool {
+		_, logged := onceSites.LoadOrStore(r.Caller.PC, true)
+		return !logged
+	}
+	return sub
+} //logger.Once()
+
+//everySite is the state of a call site that logs with Every()
+type everySite struct {
+	mutex      sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+type everyKey struct {
+	pc       uintptr
+	interval time.Duration
+}
+
+//everySites has the state of each call site and interval used with Every()
+var everySites sync.Map
+
+func (l *logger) Every(interval time.Duration) ILogger {
+	sub := l.anon(l.group)
+	sub.gate = func(r *Record) bool {
+		s, _ := everySites.LoadOrStore(everyKey{pc: r.Caller.PC, interval: interval}, &everySite{})
+		site := s.(*everySite)
+		//not r.Time, which is the same for all records in deterministic mode
+		t := time.Now()
+		site.mutex.Lock()
+		defer site.mutex.Unlock()
+		if !site.last.IsZero() && t.Sub(site.last) < interval {
+			site.suppressed++
+			return false
+		}
+		if site.suppressed > 0 {
+			r.Fields = append(append([]Field{}, r.Fields...), Field{Name: l.key("suppressed"), Value: site.suppressed})
+		}
+		site.last = t
+		site.suppressed = 0
+		return true
+	}
+	return sub
+} //logger.Every()
+
+type firstKey struct {
+	pc uintptr
+	n  int
+}
+
+//firstSites has the nr of records logged by each call site and limit used with First()
+var firstSites sync.Map
+
+func (l *logger) First(n int) ILogger {
+	sub := l.anon(l.group)
+	sub.gate = func(r *Record) bool {
+		c, _ := firstSites.LoadOrStore(firstKey{pc: r.Caller.PC, n: n}, new(int64))
+		count := atomic.AddInt64(c.(*int64), 1)
+		if count <= int64(n) {
+			return true
+		}
+		if count == int64(n)+1 {
+			r.Message = fmt.Sprintf("suppressing further messages after the first %d", n)
+			return true
+		}
+		return false
+	}
+	return sub
+} //logger.First()
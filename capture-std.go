@@ -0,0 +1,123 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+//CaptureStd redirects the stdout file descriptor and os.Stderr of the
+//process into l, so that output of fmt.Print debugging, packages that write
+//to os.Stdout/os.Stderr and C libraries that write to stdout end up in the
+//structured log. Each line is logged with data "stream" set to "stdout" at
+//InfoLevel or "stderr" at WarnLevel (not ErrorLevel, so that a line on
+//stderr does not panic when SetPanicOnError(true)).
+//The stderr file descriptor itself is not redirected: the runtime writes
+//panics and fatal errors to it just before the process dies, which would
+//be lost in a pipe that nothing reads any more. So crash output and output
+//written by C libraries to stderr still go to the original stderr (see
+//CapturePanics() to log crashes).
+//Loggers that write to os.Stdout are changed to write to the original
+//terminal or file instead, so that their records are not captured again.
+//Writers that wrap os.Stdout, like a buffered writer, cannot be changed
+//and must not be used with l, and l must not write to os.Stderr after it
+//was replaced. Other goroutines read os.Stderr without synchronization,
+//so call CaptureStd() at startup before they run, and restore after they
+//stopped. The returned func restores stdout and os.Stderr and the
+//writers after all captured output was logged, e.g.
+//	restore, err := log.CaptureStd(log.Logger("std"))
+//	if err != nil { ... }
+//	defer restore()
+//This is only supported on Linux and BSD systems, including macOS.
+func CaptureStd(l ILogger) (restore func(), err error) {
+	var wg sync.WaitGroup
+	read := func(pr *os.File, level Level, name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer pr.Close()
+			logLines(pr, l, level, name)
+		}()
+	}
+
+	//stdout file descriptor
+	fd := int(os.Stdout.Fd())
+	saved, err := dupFD(fd)
+	if err != nil {
+		return nil, fmt.Errorf("cannot capture stdout: %v", err)
+	}
+	original := os.NewFile(uintptr(saved), os.Stdout.Name())
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		original.Close()
+		return nil, fmt.Errorf("cannot capture stdout: %v", err)
+	}
+	err = redirectFD(int(pw.Fd()), fd)
+	pw.Close()
+	if err != nil {
+		pr.Close()
+		original.Close()
+		return nil, fmt.Errorf("cannot capture stdout: %v", err)
+	}
+	stdout := os.Stdout
+	replaceWriter(stdout, original)
+	read(pr, InfoLevel, "stdout")
+
+	//os.Stderr
+	stderr := os.Stderr
+	epr, epw, err := os.Pipe()
+	if err != nil {
+		redirectFD(saved, fd)
+		replaceWriter(original, stdout)
+		wg.Wait()
+		original.Close()
+		return nil, fmt.Errorf("cannot capture stderr: %v", err)
+	}
+	os.Stderr = epw
+	read(epr, WarnLevel, "stderr")
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			//closing the pipes makes logLines() get EOF
+			os.Stderr = stderr
+			epw.Close()
+			redirectFD(saved, fd)
+			wg.Wait()
+			replaceWriter(original, stdout)
+			original.Close()
+		})
+	}, nil
+} //CaptureStd()
+
+//replaceWriter changes loggers that write to old to write to w
+func replaceWriter(old, w io.Writer) {
+	if t, ok := top.(*logger); ok {
+		t.walk(func(l *logger) {
+			l.mutex.Lock()
+			defer l.mutex.Unlock()
+			if l.writer == old {
+				l.writer = w
+			}
+		})
+	}
+}
+
+//logLines logs each line read from r
+func logLines(r io.Reader, l ILogger, level Level, stream string) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			if line[len(line)-1] == '\n' {
+				line = line[:len(line)-1]
+			}
+			l.LogDepth(0, level, line, Field{Name: "stream", Value: stream})
+		}
+		if err != nil {
+			return
+		}
+	}
+} //logLines()
@@ -0,0 +1,23 @@
+//go:build linux
+// +build linux
+
+package log
+
+import "syscall"
+
+//dupFD returns a new file descriptor for the file of fd, which is closed
+//on exec so that child processes do not inherit it
+func dupFD(fd int) (int, error) {
+	saved, err := syscall.Dup(fd)
+	if err != nil {
+		return -1, err
+	}
+	syscall.CloseOnExec(saved)
+	return saved, nil
+}
+
+//redirectFD makes fd refer to the same file as oldfd
+//Dup3 is used because Dup2 is not available on all Linux architectures
+func redirectFD(oldfd, fd int) error {
+	return syscall.Dup3(oldfd, fd, 0)
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package log
+
+import "errors"
+
+//dupFD is not supported on this system
+func dupFD(fd int) (int, error) {
+	return -1, errors.New("not supported on this system")
+}
+
+//redirectFD is not supported on this system
+func redirectFD(oldfd, fd int) error {
+	return errors.New("not supported on this system")
+}
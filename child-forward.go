@@ -0,0 +1,241 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//ForwardFDEnv is the environment variable that tells a child process
+//started with StartForwarding() which file descriptor to write records to
+const ForwardFDEnv = "GO_MSVC_LOG_FORWARD_FD"
+
+//maxForwardFrame limits the size of one forwarded record
+const maxForwardFrame = 16 * 1024 * 1024
+
+//forwardGrace is how long records left in the pipe are received after the
+//child exited, before the pipe is closed
+var forwardGrace = 5 * time.Second
+
+//forwardedRecord is the JSON of a record sent from a child to its parent
+//frames consist of 4 bytes big endian length of the JSON followed by the JSON
+type forwardedRecord struct {
+	Time     time.Time              `json:"time"`
+	Level    Level                  `json:"level"`
+	Logger   string                 `json:"logger"`
+	Package  string                 `json:"package,omitempty"`
+	Function string                 `json:"function,omitempty"`
+	File     string                 `json:"file,omitempty"`
+	Line     int                    `json:"line,omitempty"`
+	Message  string                 `json:"message"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+//ParentWriter returns a writer to the parent process when this process was
+//started with StartForwarding(), else nil, e.g. in the child:
+//	if w, err := log.ParentWriter(); w != nil {
+//		log.Top().SetWriter(w)
+//	}
+func ParentWriter() (io.WriteCloser, error) {
+	s := os.Getenv(ForwardFDEnv)
+	if s == "" {
+		return nil, nil
+	}
+	fd, err := strconv.Atoi(s)
+	if err != nil || fd < 3 {
+		return nil, fmt.Errorf("invalid %s=%q", ForwardFDEnv, s)
+	}
+	return NewForwardWriter(os.NewFile(uintptr(fd), "log-forward")), nil
+}
+
+//NewForwardWriter writes records as length-prefixed JSON frames to w with
+//the time, level, logger name, caller, message and data of each record,
+//so that the receiver (see ReceiveRecords()) can log them with full
+//structure. The encoded record is not written, so the encoder of the
+//logger does not matter. Data values that cannot be written as JSON
+//are written as text.
+func NewForwardWriter(w io.Writer) io.WriteCloser {
+	return &forwardWriter{w: w}
+}
+
+type forwardWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+//Write forwards p as the message of an info record, for output that
+//was not written by a logger
+func (fw *forwardWriter) Write(p []byte) (int, error) {
+	msg, _ := trimNewline(p)
+	if err := fw.send(forwardedRecord{Time: now(), Level: InfoLevel, Message: string(msg)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (fw *forwardWriter) WriteRecord(l ILogger, r Record, encoded []byte) (int, error) {
+	fr := forwardedRecord{
+		Time:     r.Time,
+		Level:    r.Level,
+		Logger:   l.Name(),
+		Package:  r.Caller.Package,
+		Function: r.Caller.Function,
+		File:     r.Caller.File,
+		Line:     r.Caller.Line,
+		Message:  r.Message,
+		Data:     recordData(l, r),
+	}
+	if err := fw.send(fr); err != nil {
+		return 0, err
+	}
+	return len(encoded), nil
+}
+
+func (fw *forwardWriter) send(fr forwardedRecord) error {
+	b, err := json.Marshal(fr)
+	if err != nil {
+		//write values as text rather than losing the record
+		for n, v := range fr.Data {
+			fr.Data[n] = fmt.Sprintf("%+v", v)
+		}
+		if b, err = json.Marshal(fr); err != nil {
+			return err
+		}
+	}
+	frame := make([]byte, 4, 4+len(b))
+	binary.BigEndian.PutUint32(frame, uint32(len(b)))
+	frame = append(frame, b...)
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+	_, err = fw.w.Write(frame)
+	return err
+}
+
+func (fw *forwardWriter) Close() error {
+	if c, ok := fw.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+//StartForwarding starts cmd with a pipe to receive the records of the
+//child process, which gets a writer to it from ParentWriter(), and logs
+//them with l (see ReceiveRecords()). Call the returned wait func instead
+//of cmd.Wait() to wait for the child to exit and its records to be logged.
+//A grandchild started by the child inherits the pipe and keeps it open, so
+//the wait func stops receiving a few seconds after the child exited, and
+//records that a grandchild writes after that are lost.
+//This uses cmd.ExtraFiles, which is not supported on Windows.
+func StartForwarding(cmd *exec.Cmd, l ILogger) (wait func() error, err error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create forward pipe: %v", err)
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, pw)
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, ForwardFDEnv+"="+strconv.Itoa(2+len(cmd.ExtraFiles)))
+	err = cmd.Start()
+	//the child has its own copy, the reader gets EOF when the child exits
+	pw.Close()
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer pr.Close()
+		done <- ReceiveRecords(pr, l)
+	}()
+	return func() error {
+		waitErr := cmd.Wait()
+		var recvErr error
+		select {
+		case recvErr = <-done:
+		case <-time.After(forwardGrace):
+			//still open in a grandchild, closing ends ReceiveRecords()
+			pr.Close()
+			<-done
+		}
+		if waitErr != nil {
+			return waitErr
+		}
+		return recvErr
+	}, nil
+} //StartForwarding()
+
+//ReceiveRecords reads frames written by a forward writer (see NewForwardWriter())
+//until EOF and logs each record with l, keeping its time, level, caller
+//and message, with the data of the record as fields and the name of the
+//logger that wrote it in the child as "child_logger"
+func ReceiveRecords(r io.Reader, l ILogger) error {
+	br := bufio.NewReader(r)
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("cannot read forwarded record: %v", err)
+		}
+		size := binary.BigEndian.Uint32(header)
+		if size > maxForwardFrame {
+			return fmt.Errorf("forwarded record of %d bytes too big", size)
+		}
+		b := make([]byte, size)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return fmt.Errorf("cannot read forwarded record: %v", err)
+		}
+		var fr forwardedRecord
+		if err := json.Unmarshal(b, &fr); err != nil {
+			return fmt.Errorf("cannot decode forwarded record: %v", err)
+		}
+		logForwarded(l, fr)
+	}
+} //ReceiveRecords()
+
+func logForwarded(l ILogger, fr forwardedRecord) {
+	names := make([]string, 0, len(fr.Data))
+	for n := range fr.Data {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fields := make([]Field, 0, len(names)+1)
+	if fr.Logger != "" {
+		fields = append(fields, Field{Name: "child_logger", Value: fr.Logger})
+	}
+	for _, n := range names {
+		fields = append(fields, Field{Name: n, Value: fr.Data[n]})
+	}
+	ll, ok := l.(*logger)
+	if !ok {
+		l.LogDepth(1, fr.Level, fr.Message, fields...)
+		return
+	}
+	if !ll.enabled(fr.Level) {
+		return
+	}
+	ll.write(Record{
+		Time: fr.Time,
+		Caller: Caller{
+			Package:  fr.Package,
+			Function: fr.Function,
+			File:     fr.File,
+			Line:     fr.Line,
+		},
+		Level:   fr.Level,
+		Message: fr.Message,
+		Fields:  fields,
+		Data:    ll.Data(),
+	})
+} //logForwarded()
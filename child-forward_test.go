@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForwardRoundTrip(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	child := Top().Temp("xchild").WithWriter(NewForwardWriter(pw)).With("job", "import")
+	_, _, line, _ := runtime.Caller(0)
+	child.LogDepth(0, WarnLevel, "disk full", Field{Name: "percent", Value: 90})
+	pw.Close()
+
+	buf := &bytes.Buffer{}
+	parent := Top().Temp("xparent").WithWriter(buf).WithEncoder(NewJSONEncoder())
+	if err := ReceiveRecords(pr, parent); err != nil {
+		t.Fatal(err)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &obj); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf, err)
+	}
+	want := map[string]interface{}{
+		"level":        "warn",
+		"message":      "disk full",
+		"logger":       "/xparent",
+		"child_logger": "/xchild",
+		"job":          "import",
+		"percent":      float64(90),
+	}
+	for n, v := range want {
+		if obj[n] != v {
+			t.Fatalf("%s = %v, want %v in %s", n, obj[n], v, buf)
+		}
+	}
+	if caller := fmt.Sprint(obj["caller"]); !strings.HasSuffix(caller, fmt.Sprintf("child-forward_test.go:%d", line+1)) {
+		t.Fatalf("caller %s", caller)
+	}
+}
+
+func TestReceiveRecordsErrors(t *testing.T) {
+	header := func(size uint32) []byte {
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, size)
+		return b
+	}
+	tests := []struct {
+		name    string
+		frames  []byte
+		wantErr string
+	}{
+		{"empty", nil, ""},
+		{"too big", header(maxForwardFrame + 1), "too big"},
+		{"truncated", append(header(10), "{}"...), "cannot read"},
+		{"partial header", []byte{0, 0}, "cannot read"},
+		{"invalid JSON", append(header(2), "{x"...), "cannot decode"},
+	}
+	l := Top().Temp("xparent").WithWriter(&bytes.Buffer{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr, pw, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer pr.Close()
+			pw.Write(tt.frames)
+			pw.Close()
+			err = ReceiveRecords(pr, l)
+			if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStartForwardingWithGrandchild(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ExtraFiles is not supported on Windows")
+	}
+	defer func(grace time.Duration) { forwardGrace = grace }(forwardGrace)
+	forwardGrace = 100 * time.Millisecond
+	//the background sleep inherits the pipe and outlives the shell
+	cmd := exec.Command("sh", "-c", "sleep 5 &")
+	wait, err := StartForwarding(cmd, Top().Temp("xparent").WithWriter(&bytes.Buffer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Fatalf("wait took %v", d)
+	}
+}
@@ -0,0 +1,262 @@
+package log
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//ColorEncoder returns the default encoder with the level written in color
+func ColorEncoder() IColumnEncoder {
+	return DefaultEncoder(EncoderWithColor())
+}
+
+//ColorLevelText writes the level of the log record in color
+//if the console supports it (see ColorEnabled())
+func ColorLevelText(width int) ITextValue {
+	return ThemeLevelText(DefaultTheme(), width)
+}
+
+//ThemeLevelText writes the level of the log record with the label and color
+//from the theme, e.g. ThemeLevelText(ShortTheme(), 3) for "DBG", "INF", ...
+func ThemeLevelText(theme Theme, width int) ITextValue {
+	return themeLevelText{theme: theme, width: width}
+}
+
+//ANSIColor is one of the 16 standard terminal colors
+type ANSIColor int
+
+//Colors for LevelStyle Foreground and Background
+const (
+	//NoColor keeps the terminal color
+	NoColor ANSIColor = iota
+	Black
+	Red
+	Green
+	Yellow
+	Blue
+	Magenta
+	Cyan
+	White
+	BrightBlack
+	BrightRed
+	BrightGreen
+	BrightYellow
+	BrightBlue
+	BrightMagenta
+	BrightCyan
+	BrightWhite
+)
+
+//sgr returns the SGR parameter for the color as foreground, add 10 for background
+func (c ANSIColor) sgr() int {
+	if c >= BrightBlack {
+		return 90 + int(c-BrightBlack)
+	}
+	return 30 + int(c-Black)
+}
+
+//LevelStyle is how a level is written in the console
+type LevelStyle struct {
+	//Label is written instead of the level name, e.g. "INF" or "✖",
+	//or "" to write the level label (see SetLevelName())
+	Label      string
+	Foreground ANSIColor
+	Background ANSIColor
+	Bold       bool
+	//Color is ANSI SGR parameters, e.g. "31" for red or "1;31" for bold red,
+	//used instead of Foreground, Background and Bold when not ""
+	Color string
+}
+
+//SGR returns the ANSI SGR parameters of the style, e.g. "1;31",
+//or "" to write the label without color
+func (s LevelStyle) SGR() string {
+	if s.Color != "" {
+		return s.Color
+	}
+	sgr := ""
+	if s.Bold {
+		sgr = "1"
+	}
+	if s.Foreground != NoColor {
+		sgr += ";" + strconv.Itoa(s.Foreground.sgr())
+	}
+	if s.Background != NoColor {
+		sgr += ";" + strconv.Itoa(s.Background.sgr()+10)
+	}
+	return strings.TrimPrefix(sgr, ";")
+}
+
+//Theme defines the style of each level
+type Theme struct {
+	Styles map[Level]LevelStyle
+}
+
+//DefaultTheme writes the level names (see SetLevelName()) in color
+//for terminals with a dark background, i.e. DarkTheme()
+func DefaultTheme() Theme {
+	return DarkTheme()
+}
+
+//DarkTheme uses colors that are readable on a dark background
+func DarkTheme() Theme {
+	return Theme{Styles: map[Level]LevelStyle{
+		TraceLevel: {Foreground: BrightBlack},
+		DebugLevel: {Foreground: Cyan},
+		InfoLevel:  {Foreground: Green},
+		WarnLevel:  {Foreground: Yellow},
+		ErrorLevel: {Foreground: Red},
+		PanicLevel: {Foreground: Red, Bold: true},
+		FatalLevel: {Foreground: Magenta, Bold: true},
+	}}
+}
+
+//LightTheme uses colors that are readable on a light background,
+//with warnings in black on yellow rather than yellow text
+func LightTheme() Theme {
+	return Theme{Styles: map[Level]LevelStyle{
+		TraceLevel: {Foreground: BrightBlack},
+		DebugLevel: {Foreground: Blue},
+		InfoLevel:  {Foreground: Green},
+		WarnLevel:  {Foreground: Black, Background: BrightYellow},
+		ErrorLevel: {Foreground: Red, Bold: true},
+		PanicLevel: {Foreground: White, Background: Red, Bold: true},
+		FatalLevel: {Foreground: White, Background: Magenta, Bold: true},
+	}}
+}
+
+//MonochromeBoldTheme uses no colors but writes warnings and worse in bold,
+//for terminals or users that cannot distinguish colors
+func MonochromeBoldTheme() Theme {
+	return Theme{Styles: map[Level]LevelStyle{
+		WarnLevel:  {Bold: true},
+		ErrorLevel: {Bold: true},
+		PanicLevel: {Bold: true},
+		FatalLevel: {Bold: true},
+	}}
+}
+
+//ShortTheme writes three letter level names in color
+func ShortTheme() Theme {
+	return DefaultTheme().WithLabels(ShortLabels())
+}
+
+//GlyphTheme writes a single character per level in color
+func GlyphTheme() Theme {
+	return DefaultTheme().WithLabels(GlyphLabels())
+}
+
+//ShortLabels are three letter level names, e.g. LightTheme().WithLabels(ShortLabels())
+func ShortLabels() map[Level]string {
+	return map[Level]string{
+		TraceLevel: "TRC",
+		DebugLevel: "DBG",
+		InfoLevel:  "INF",
+		WarnLevel:  "WRN",
+		ErrorLevel: "ERR",
+		PanicLevel: "PNC",
+		FatalLevel: "FTL",
+	}
+}
+
+//GlyphLabels are single character level names
+func GlyphLabels() map[Level]string {
+	return map[Level]string{
+		TraceLevel: "·",
+		DebugLevel: "•",
+		InfoLevel:  "i",
+		WarnLevel:  "!",
+		ErrorLevel: "✖",
+		PanicLevel: "‼",
+		FatalLevel: "☠",
+	}
+}
+
+//WithLabels returns a copy of the theme with the labels of the levels
+//replaced and their colors kept
+func (t Theme) WithLabels(labels map[Level]string) Theme {
+	for level, label := range labels {
+		style := t.Style(level)
+		style.Label = label
+		t = t.With(level, style)
+	}
+	return t
+}
+
+//With returns a copy of the theme with the style of one level replaced
+func (t Theme) With(level Level, style LevelStyle) Theme {
+	styles := map[Level]LevelStyle{}
+	for l, s := range t.Styles {
+		styles[l] = s
+	}
+	styles[level] = style
+	return Theme{Styles: styles}
+}
+
+//Style of the level, which is the level name without color if
+//the level is not defined in the theme
+func (t Theme) Style(level Level) LevelStyle {
+	if s, ok := t.Styles[level]; ok {
+		return s
+	}
+	return LevelStyle{}
+}
+
+var (
+	colorOnce    sync.Once
+	colorMutex   sync.Mutex
+	colorEnabled bool
+)
+
+//ColorEnabled is true when color escape sequences can be written to
+//the console, i.e. stderr is a terminal rather than a file or pipe.
+//On Windows this enables virtual terminal processing on the stdout and
+//stderr console handles the first time it is called and it is false if
+//that fails for stderr, so that cmd.exe does not show escape sequences
+//as text.
+func ColorEnabled() bool {
+	colorOnce.Do(func() {
+		enableVirtualTerminal(os.Stdout)
+		enabled := enableVirtualTerminal(os.Stderr)
+		colorMutex.Lock()
+		colorEnabled = enabled
+		colorMutex.Unlock()
+	})
+	colorMutex.Lock()
+	defer colorMutex.Unlock()
+	return colorEnabled
+}
+
+//SetColor overrides the detected color support, e.g. to disable colors
+//when output is not a terminal
+func SetColor(enabled bool) {
+	colorOnce.Do(func() {})
+	colorMutex.Lock()
+	defer colorMutex.Unlock()
+	colorEnabled = enabled
+}
+
+//colorText wraps s in ANSI color escape sequences
+func colorText(sgr, s string) string {
+	if sgr == "" || !ColorEnabled() {
+		return s
+	}
+	return "\x1b[" + sgr + "m" + s + "\x1b[0m"
+}
+
+//============================================================================
+type themeLevelText struct {
+	theme Theme
+	width int
+}
+
+func (c themeLevelText) Text(l ILogger, r Record) string {
+	style := c.theme.Style(r.Level)
+	if style.Label == "" {
+		style.Label = r.Level.Label()
+	}
+	//pad before adding color so escape sequences do not count in the width
+	return colorText(style.SGR(), textField(c.width, style.Label))
+}
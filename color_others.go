@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+import "os"
+
+//enableVirtualTerminal is only needed on Windows, other terminals
+//process ANSI escape sequences, so this is only false when f is not a
+//terminal, e.g. output redirected to a file or pipe
+func enableVirtualTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,449 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+//Config is the configuration of a tree of loggers as written by
+//ILogger.SaveConfig() and applied by LoadConfig(), e.g.
+//	{"loggers":[
+//		{"name":"/","level":"info","encoder":{"kind":"json"},"writer":{"kind":"stderr"}},
+//		{"name":"db","level":"debug"}
+//	]}
+//Loggers are listed parents first. Encoder and writer are only listed
+//where they differ from the parent, because children inherit them.
+type Config struct {
+	Loggers []LoggerConfig `json:"loggers"`
+}
+
+//LoggerConfig is the configuration of one logger
+type LoggerConfig struct {
+	//Name is the logger path, e.g. "github.com/go-msvc/log", or "/" for Top()
+	Name    string         `json:"name"`
+	Level   Level          `json:"level"`
+	Encoder *EncoderConfig `json:"encoder,omitempty"`
+	Writer  *WriterConfig  `json:"writer,omitempty"`
+}
+
+//EncoderConfig describes an encoder
+//Kind is "json" for NewJSONEncoder(), "console" for DefaultEncoder(),
+//"filter_fields" for FilterFields() and "scan_secrets" for ScanSecrets()
+//of Encoder, or "none" (left unchanged when loaded).
+//Options for "json": "max_depth", "time_format", "escape_html", "invalid_utf8"
+//("replace" or "reject"), "keys" (renamed standard keys), "static" (values)
+//and "caller_format" (CallerFormat).
+//Options for "console": "time_format", "color", "theme", "caller",
+//"caller_format" and "fields". Column encoders with other columns than
+//DefaultEncoder() and its options are saved with their Go type as kind.
+//Options for "filter_fields": "allow" and "deny".
+//Options for "scan_secrets": "patterns".
+//Other encoders, e.g. with keys for signing or pseudonyms that must not
+//be written to a file, are saved with their Go type as kind and cannot be
+//loaded: set them in code after loading the config.
+type EncoderConfig struct {
+	Kind    string                 `json:"kind"`
+	Options map[string]interface{} `json:"options,omitempty"`
+	//Encoder is the wrapped encoder of "filter_fields" and "scan_secrets"
+	Encoder *EncoderConfig `json:"encoder,omitempty"`
+}
+
+//WriterConfig describes a writer
+//Kind is "stdout", "stderr", "discard", "none" (left unchanged when loaded),
+//"file" with the file name as target, which is opened for append when
+//loaded, or "multi" for NewMultiWriter() with sinks.
+//Other writers, e.g. with network connections, keys or callbacks, are saved
+//with their Go type as kind and cannot be loaded: set them in code after
+//loading the config.
+type WriterConfig struct {
+	Kind   string       `json:"kind"`
+	Target string       `json:"target,omitempty"`
+	Sinks  []SinkConfig `json:"sinks,omitempty"`
+}
+
+//SinkConfig describes a sink of a "multi" writer
+type SinkConfig struct {
+	Writer *WriterConfig `json:"writer"`
+	//Encoder is nil for sinks that write the record as encoded by the logger
+	Encoder *EncoderConfig `json:"encoder,omitempty"`
+}
+
+//SaveConfig writes the effective configuration of this logger and all
+//its children to a JSON file that LoadConfig() accepts, e.g.
+//Top().SaveConfig("log.json") to capture what is running now
+func (l *logger) SaveConfig(path string) error {
+	b, err := json.MarshalIndent(l.Config(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode log config: %v", err)
+	}
+	if err := ioutil.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("cannot write log config: %v", err)
+	}
+	return nil
+} //logger.SaveConfig()
+
+//Config returns the effective configuration of this logger and all its children
+func (l *logger) Config() Config {
+	c := Config{Loggers: []LoggerConfig{}}
+	encoders := map[*logger]*EncoderConfig{}
+	writers := map[*logger]*WriterConfig{}
+	l.walk(func(sl *logger) {
+		sl.mutex.Lock()
+		level, encoder, writer := sl.level, sl.encoder, sl.writer
+		sl.mutex.Unlock()
+		name := sl.Name()
+		if name == "" {
+			name = "/"
+		}
+		lc := LoggerConfig{Name: name, Level: level}
+		encoders[sl] = encoderConfig(encoder)
+		writers[sl] = writerConfig(writer)
+		parent, _ := sl.parent.(*logger)
+		if sl == l || parent == nil || !sameJSON(encoders[parent], encoders[sl]) {
+			lc.Encoder = encoders[sl]
+		}
+		if sl == l || parent == nil || !sameJSON(writers[parent], writers[sl]) {
+			lc.Writer = writers[sl]
+		}
+		c.Loggers = append(c.Loggers, lc)
+	})
+	return c
+} //logger.Config()
+
+//LoadConfig reads a JSON file written by ILogger.SaveConfig() and applies it
+func LoadConfig(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read log config: %v", err)
+	}
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return fmt.Errorf("cannot decode log config %s: %v", path, err)
+	}
+	return ApplyConfig(c)
+} //LoadConfig()
+
+//ApplyConfig configures the loggers in the order listed, creating
+//loggers that do not yet exist. All loggers are configured even when
+//some encoders or writers cannot be created, and then the first such
+//error is returned.
+func ApplyConfig(c Config) error {
+	var firstErr error
+	files := map[string]io.Writer{}
+	for _, lc := range c.Loggers {
+		l := Top()
+		if name := strings.Trim(lc.Name, "/"); name != "" {
+			l = Logger(name)
+		}
+		l.SetLevel(lc.Level)
+		if lc.Encoder != nil {
+			e, err := lc.Encoder.encoder()
+			if err == nil {
+				l.SetEncoder(e)
+			} else if firstErr == nil {
+				firstErr = fmt.Errorf("logger %s: %v", lc.Name, err)
+			}
+		}
+		if lc.Writer != nil {
+			w, err := lc.Writer.writer(files)
+			if err == nil {
+				l.SetWriter(w)
+			} else if firstErr == nil {
+				firstErr = fmt.Errorf("logger %s: %v", lc.Name, err)
+			}
+		}
+	}
+	return firstErr
+} //ApplyConfig()
+
+//sameJSON is true when a and b encode the same, used to compare configs
+func sameJSON(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func encoderConfig(e IEncoder) *EncoderConfig {
+	switch e := e.(type) {
+	case nil:
+		return &EncoderConfig{Kind: "none"}
+	case jsonEncoder:
+		opts := map[string]interface{}{
+			"max_depth":   e.maxDepth,
+			"time_format": e.timeFormat,
+		}
+		if e.escapeHTML {
+			opts["escape_html"] = true
+		}
+		if e.invalidUTF8 == RejectInvalidUTF8 {
+			opts["invalid_utf8"] = "reject"
+		}
+		if len(e.keys) > 0 {
+			opts["keys"] = e.keys
+		}
+		if len(e.static) > 0 {
+			static := map[string]interface{}{}
+			for _, f := range e.static {
+				static[f.name] = f.value
+			}
+			opts["static"] = static
+		}
+		if e.callerFormat != (CallerFormat{}) {
+			opts["caller_format"] = e.callerFormat
+		}
+		return &EncoderConfig{Kind: "json", Options: opts}
+	case columnEncoder:
+		opts := map[string]interface{}{
+			"color":  false,
+			"caller": false,
+			"fields": false,
+		}
+		for _, col := range e.columns {
+			c, _ := col.(column)
+			switch t := c.text.(type) {
+			case timeText:
+				opts["time_format"] = t.fmt
+			case themeLevelText:
+				opts["color"] = true
+				opts["theme"] = t.theme
+			case codeText:
+				opts["caller"] = true
+				if t.format != CodeText(consoleCodeWidth).(codeText).format {
+					opts["caller_format"] = t.format
+				}
+			case fieldsText:
+				opts["fields"] = true
+			}
+		}
+		//other layouts than those of DefaultEncoder() options are not loadable
+		ec := &EncoderConfig{Kind: "console", Options: opts}
+		if loaded, err := ec.encoder(); err != nil || !reflect.DeepEqual(loaded, IEncoder(e)) {
+			return &EncoderConfig{Kind: fmt.Sprintf("%T", e)}
+		}
+		return ec
+	case fieldFilterEncoder:
+		opts := map[string]interface{}{}
+		if len(e.filter.Allow) > 0 {
+			opts["allow"] = e.filter.Allow
+		}
+		if len(e.filter.Deny) > 0 {
+			opts["deny"] = e.filter.Deny
+		}
+		return &EncoderConfig{Kind: "filter_fields", Options: opts, Encoder: encoderConfig(e.encoder)}
+	case secretScanner:
+		patterns := []string{}
+		for _, p := range e.patterns {
+			patterns = append(patterns, p.String())
+		}
+		opts := map[string]interface{}{"patterns": patterns}
+		return &EncoderConfig{Kind: "scan_secrets", Options: opts, Encoder: encoderConfig(e.encoder)}
+	default:
+		return &EncoderConfig{Kind: fmt.Sprintf("%T", e)}
+	}
+} //encoderConfig()
+
+func (ec EncoderConfig) encoder() (IEncoder, error) {
+	opts := ec.Options
+	str := func(n string) string {
+		s, _ := opts[n].(string)
+		return s
+	}
+	flag := func(n string, def bool) bool {
+		if b, ok := opts[n].(bool); ok {
+			return b
+		}
+		return def
+	}
+	list := func(n string) []string {
+		var l []string
+		if items, ok := opts[n].([]interface{}); ok {
+			for _, item := range items {
+				s, _ := item.(string)
+				l = append(l, s)
+			}
+		}
+		return l
+	}
+	switch ec.Kind {
+	case "none":
+		return nil, nil
+	case "json":
+		je := NewJSONEncoder().
+			WithTimeFormat(str("time_format")).
+			WithEscapeHTML(flag("escape_html", false))
+		if d, ok := opts["max_depth"].(float64); ok {
+			je = je.WithMaxDepth(int(d))
+		}
+		if cf, ok := opts["caller_format"]; ok {
+			var f CallerFormat
+			if err := decodeOption(cf, &f); err != nil {
+				return nil, fmt.Errorf("invalid caller_format: %v", err)
+			}
+			je = je.WithCallerFormat(f)
+		}
+		switch str("invalid_utf8") {
+		case "", "replace":
+		case "reject":
+			je = je.WithInvalidUTF8(RejectInvalidUTF8)
+		default:
+			return nil, fmt.Errorf("unknown invalid_utf8 %q", str("invalid_utf8"))
+		}
+		if keys, ok := opts["keys"].(map[string]interface{}); ok {
+			for standard, name := range keys {
+				if !isJSONStandardKey(standard) {
+					return nil, fmt.Errorf("unknown standard key %q", standard)
+				}
+				s, _ := name.(string)
+				je = je.WithKey(standard, s)
+			}
+		}
+		if static, ok := opts["static"].(map[string]interface{}); ok {
+			for name, value := range static {
+				je = je.WithStatic(name, value)
+			}
+		}
+		return je, nil
+	case "console":
+		options := []EncoderOption{}
+		if f := str("time_format"); f != "" {
+			options = append(options, EncoderWithTimeFormat(f))
+		}
+		if theme, ok := opts["theme"]; ok {
+			var t Theme
+			if err := decodeOption(theme, &t); err != nil {
+				return nil, fmt.Errorf("invalid theme: %v", err)
+			}
+			options = append(options, EncoderWithTheme(t))
+		} else if flag("color", false) {
+			options = append(options, EncoderWithColor())
+		}
+		if !flag("caller", true) {
+			options = append(options, EncoderWithoutCaller())
+		} else if cf, ok := opts["caller_format"]; ok {
+			var f CallerFormat
+			if err := decodeOption(cf, &f); err != nil {
+				return nil, fmt.Errorf("invalid caller_format: %v", err)
+			}
+			options = append(options, func(ce IColumnEncoder) IColumnEncoder {
+				return ce.Replace("code", Column("code", CodeTextFormat(consoleCodeWidth, f)))
+			})
+		}
+		if flag("fields", false) {
+			options = append(options, EncoderWithFields())
+		}
+		return DefaultEncoder(options...), nil
+	case "filter_fields", "scan_secrets":
+		if ec.Encoder == nil {
+			return nil, fmt.Errorf("%s without encoder", ec.Kind)
+		}
+		e, err := ec.Encoder.encoder()
+		if err != nil {
+			return nil, err
+		}
+		if ec.Kind == "filter_fields" {
+			return FilterFields(e, FieldFilter{Allow: list("allow"), Deny: list("deny")}), nil
+		}
+		patterns := []*regexp.Regexp{}
+		for _, p := range list("patterns") {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid secret pattern: %v", err)
+			}
+			patterns = append(patterns, re)
+		}
+		return ScanSecrets(e, patterns...), nil
+	default:
+		return nil, fmt.Errorf("cannot load encoder kind %q", ec.Kind)
+	}
+} //EncoderConfig.encoder()
+
+//decodeOption decodes a generic JSON option value, e.g. a theme, into v
+func decodeOption(value interface{}, v interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func writerConfig(w io.Writer) *WriterConfig {
+	switch w {
+	case nil:
+		return &WriterConfig{Kind: "none"}
+	case io.Writer(os.Stdout):
+		return &WriterConfig{Kind: "stdout"}
+	case io.Writer(os.Stderr):
+		return &WriterConfig{Kind: "stderr"}
+	case ioutil.Discard:
+		return &WriterConfig{Kind: "discard"}
+	}
+	switch w := w.(type) {
+	case *os.File:
+		return &WriterConfig{Kind: "file", Target: w.Name()}
+	case *multiWriter:
+		wc := &WriterConfig{Kind: "multi", Sinks: []SinkConfig{}}
+		for _, s := range w.sinks {
+			sc := SinkConfig{Writer: writerConfig(s.Writer)}
+			if s.Encoder != nil {
+				sc.Encoder = encoderConfig(s.Encoder)
+			}
+			wc.Sinks = append(wc.Sinks, sc)
+		}
+		return wc
+	}
+	return &WriterConfig{Kind: fmt.Sprintf("%T", w)}
+} //writerConfig()
+
+//writer creates the writer, opening each file only once
+func (wc WriterConfig) writer(files map[string]io.Writer) (io.Writer, error) {
+	switch wc.Kind {
+	case "none":
+		return nil, nil
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	case "discard":
+		return ioutil.Discard, nil
+	case "file":
+		if w, ok := files[wc.Target]; ok {
+			return w, nil
+		}
+		f, err := os.OpenFile(wc.Target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open log file: %v", err)
+		}
+		files[wc.Target] = f
+		return f, nil
+	case "multi":
+		sinks := []Sink{}
+		for _, sc := range wc.Sinks {
+			if sc.Writer == nil {
+				return nil, fmt.Errorf("multi writer sink without writer")
+			}
+			w, err := sc.Writer.writer(files)
+			if err != nil {
+				return nil, err
+			}
+			if w == nil {
+				return nil, fmt.Errorf("multi writer sink without writer")
+			}
+			s := Sink{Writer: w}
+			if sc.Encoder != nil {
+				if s.Encoder, err = sc.Encoder.encoder(); err != nil {
+					return nil, err
+				}
+			}
+			sinks = append(sinks, s)
+		}
+		return NewMultiWriter(sinks...), nil
+	default:
+		return nil, fmt.Errorf("cannot load writer kind %q", wc.Kind)
+	}
+} //WriterConfig.writer()
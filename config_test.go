@@ -0,0 +1,102 @@
+package log
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f, err := os.Create(filepath.Join(dir, "log.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name    string
+		encoder IEncoder
+	}{
+		{"theme", DefaultEncoder(EncoderWithTheme(LightTheme().WithLabels(ShortLabels())))},
+		{"filter fields", FilterFields(NewJSONEncoder(), FieldFilter{Allow: []string{"a*"}, Deny: []string{"ab"}})},
+		{"scan secrets", ScanSecrets(NewJSONEncoder(), regexp.MustCompile(`secret-\w+`))},
+		{"nested", ScanSecrets(FilterFields(DefaultEncoder(EncoderWithFields()), FieldFilter{Deny: []string{"sql"}}))},
+		{"json caller format", NewJSONEncoder().WithCallerFormat(CallerFormat{File: BaseFile, PadLine: true, ZeroPad: true})},
+		{"console caller format", DefaultEncoder(func(ce IColumnEncoder) IColumnEncoder {
+			return ce.Replace("code", Column("code", CodeTextFormat(consoleCodeWidth, CallerFormat{File: BaseFile})))
+		})},
+	}
+	//the record written before and after the config was applied
+	r := Record{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   InfoLevel,
+		Message: "hello",
+		Caller:  Caller{File: "/src/app/main.go", Line: 42},
+		Fields:  []Field{{Name: "a", Value: 1}, {Name: "sql", Value: "select 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := Logger("configtest")
+			l.SetEncoder(tt.encoder)
+			l.SetWriter(NewMultiWriter(
+				Sink{Writer: os.Stderr},
+				Sink{Writer: f, Encoder: FilterFields(NewJSONEncoder(), FieldFilter{Deny: []string{"sql"}})},
+			))
+			before := tt.encoder.Encode(l, r)
+			saved := l.(*logger).Config()
+			b, _ := json.Marshal(saved)
+			var loaded Config
+			if err := json.Unmarshal(b, &loaded); err != nil {
+				t.Fatal(err)
+			}
+			l.SetEncoder(NewJSONEncoder())
+			l.SetWriter(os.Stdout)
+			if err := ApplyConfig(loaded); err != nil {
+				t.Fatalf("ApplyConfig() = %v\n%s", err, b)
+			}
+			if again := l.(*logger).Config(); !sameJSON(saved, again) {
+				again, _ := json.Marshal(again)
+				t.Fatalf("config changed:\n%s\n%s", b, again)
+			}
+			if after := l.(*logger).encoder.Encode(l, r); string(after) != string(before) {
+				t.Fatalf("record encoded differently after loading:\n%s\n%s", before, after)
+			}
+		})
+	}
+}
+
+func TestConfigRejectsUnknownKinds(t *testing.T) {
+	ec := encoderConfig(SignRecords(NewJSONEncoder(), NewHMACKeyRing("k1", []byte("secret"))))
+	if _, err := ec.encoder(); err == nil {
+		t.Fatalf("loaded encoder kind %q", ec.Kind)
+	}
+}
+
+func TestConfigRejectsUnknownJSONKeys(t *testing.T) {
+	ec := EncoderConfig{Kind: "json", Options: map[string]interface{}{
+		"keys": map[string]interface{}{"msg": "message"},
+	}}
+	if _, err := ec.encoder(); err == nil {
+		t.Fatal("loaded unknown standard key")
+	}
+}
+
+func TestConfigSavesOtherColumnsByType(t *testing.T) {
+	e := NewColumnEncoder().With(Column("msg", MessageText(0)))
+	ec := encoderConfig(e)
+	if ec.Kind == "console" {
+		t.Fatalf("saved custom columns as %q", ec.Kind)
+	}
+	if _, err := ec.encoder(); err == nil {
+		t.Fatalf("loaded custom columns from kind %q", ec.Kind)
+	}
+}
@@ -1,19 +1,73 @@
 package log
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
 
 //DefaultEncoder returns a default encoder for normal terminal/console log output
-func DefaultEncoder() IColumnEncoder {
-	ce := NewColumnEncoder().
+//with options to change it slightly, e.g. DefaultEncoder(EncoderWithoutCaller(), EncoderWithColor())
+func DefaultEncoder(opts ...EncoderOption) IColumnEncoder {
+	var ce IColumnEncoder = NewColumnEncoder().
 		With(Column("time", TimeText("2006-01-02 15:04:05.000"))).
 		With(Column("level", LevelText(5))).
 		With(Column("logger", NameText(10))).
 		With(Column("module", ModuleText(15))).
-		With(Column("code", CodeText(30))).
+		With(Column("code", CodeText(consoleCodeWidth))).
 		With(Column("message", MessageText(0)))
+	for _, opt := range opts {
+		if opt != nil {
+			ce = opt(ce)
+		}
+	}
 	return ce
 }
 
+//consoleCodeWidth is the width of the code column of DefaultEncoder()
+const consoleCodeWidth = 30
+
+//EncoderOption changes the columns of DefaultEncoder()
+type EncoderOption func(IColumnEncoder) IColumnEncoder
+
+//EncoderWithoutCaller removes the module and code columns
+func EncoderWithoutCaller() EncoderOption {
+	return func(ce IColumnEncoder) IColumnEncoder {
+		return ce.Remove("module").Remove("code")
+	}
+}
+
+//EncoderWithTimeFormat sets the time layout of the time column
+func EncoderWithTimeFormat(format string) EncoderOption {
+	return func(ce IColumnEncoder) IColumnEncoder {
+		return ce.Replace("time", Column("time", TimeText(format)))
+	}
+}
+
+//EncoderWithColor writes the level in color (see ColorLevelText())
+func EncoderWithColor() EncoderOption {
+	return func(ce IColumnEncoder) IColumnEncoder {
+		return ce.Replace("level", Column("level", ColorLevelText(5)))
+	}
+}
+
+//EncoderWithTheme writes the level with the labels and colors of the theme,
+//e.g. EncoderWithTheme(LightTheme()) for terminals with a light background
+func EncoderWithTheme(theme Theme) EncoderOption {
+	return func(ce IColumnEncoder) IColumnEncoder {
+		return ce.Replace("level", Column("level", ThemeLevelText(theme, 5)))
+	}
+}
+
+//EncoderWithFields adds a column with the data and fields of each record (see FieldsText())
+func EncoderWithFields() EncoderOption {
+	return func(ce IColumnEncoder) IColumnEncoder {
+		return ce.With(Column("fields", FieldsText(0)))
+	}
+}
+
 //NewColumnEncoder ...
 func NewColumnEncoder() IColumnEncoder {
 	return columnEncoder{
@@ -43,10 +97,17 @@ func ModuleText(width int) ITextValue {
 
 //CodeText writes the file name and line number
 func CodeText(width int) ITextValue {
-	return codeText{width: width}
+	return codeText{width: width, format: CallerFormat{File: FullFile, PadLine: true}}
 }
 
-//MessageText writes the log message
+//CodeTextFormat writes the file name and line number in the specified format, e.g.
+//CodeTextFormat(20, CallerFormat{File: ShortFile}) for "pkg/server/handler.go:42"
+func CodeTextFormat(width int, format CallerFormat) ITextValue {
+	return codeText{width: width, format: format}
+}
+
+//MessageText writes the log message, or "event: <name>" for records from
+//ILogger.Event()
 func MessageText(width int) ITextValue {
 	return messageText{width: width}
 }
@@ -59,11 +120,33 @@ func DataText(fmt, name string, width int) ITextValue {
 	return dataText{fmt: fmt, name: name, width: width}
 }
 
+//FieldsText writes all data values and record fields as name=value pairs sorted by name,
+//with grouped values written as dotted names, e.g. "http.method=GET"
+func FieldsText(width int) ITextValue {
+	return fieldsText{width: width}
+}
+
+//FuncText is a named column that writes the text returned by fn, to add
+//bespoke columns without implementing ITextValue, e.g.
+//	ce.With(FuncText("pod", func(l ILogger, r Record) string { return podName }))
+func FuncText(name string, fn func(ILogger, Record) string) IColumn {
+	return Column(name, funcText{fn: fn})
+}
+
 //IColumnEncoder manages an array of encoders to make up one line of console logging
 type IColumnEncoder interface {
 	IEncoder
 	Columns() []IColumn
 	With(...IColumn) IColumnEncoder
+	//Remove returns the encoder without the named column
+	Remove(name string) IColumnEncoder
+	//Insert returns the encoder with the column inserted before index,
+	//or appended if index is past the end
+	Insert(index int, col IColumn) IColumnEncoder
+	//Replace returns the encoder with the named column replaced,
+	//or unchanged if there is no such column, e.g.
+	//	DefaultEncoder().Replace("message", Column("message", MessageText(80)))
+	Replace(name string, col IColumn) IColumnEncoder
 }
 
 //columnEncoder implements IColumnEncoder
@@ -78,6 +161,45 @@ func (ce columnEncoder) With(cols ...IColumn) IColumnEncoder {
 	return ce
 }
 
+func (ce columnEncoder) Remove(name string) IColumnEncoder {
+	columns := make([]IColumn, 0, len(ce.columns))
+	for _, col := range ce.columns {
+		if col.Name() != name {
+			columns = append(columns, col)
+		}
+	}
+	ce.columns = columns
+	return ce
+}
+
+func (ce columnEncoder) Insert(index int, col IColumn) IColumnEncoder {
+	if index < 0 {
+		index = 0
+	}
+	if index > len(ce.columns) {
+		index = len(ce.columns)
+	}
+	columns := make([]IColumn, 0, len(ce.columns)+1)
+	columns = append(columns, ce.columns[:index]...)
+	columns = append(columns, col)
+	columns = append(columns, ce.columns[index:]...)
+	ce.columns = columns
+	return ce
+}
+
+func (ce columnEncoder) Replace(name string, col IColumn) IColumnEncoder {
+	columns := make([]IColumn, len(ce.columns))
+	for i, c := range ce.columns {
+		if c.Name() == name {
+			columns[i] = col
+		} else {
+			columns[i] = c
+		}
+	}
+	ce.columns = columns
+	return ce
+}
+
 //Column to add to list of columns
 func Column(name string, text ITextValue) IColumn {
 	return column{
@@ -141,7 +263,7 @@ type levelText struct {
 }
 
 func (c levelText) Text(l ILogger, r Record) string {
-	return textField(c.width, fmt.Sprintf("%s", r.Level))
+	return textField(c.width, r.Level.Label())
 }
 
 //============================================================================
@@ -164,11 +286,16 @@ func (c moduleText) Text(l ILogger, r Record) string {
 
 //============================================================================
 type codeText struct {
-	width int
+	width  int
+	format CallerFormat
 }
 
 func (c codeText) Text(l ILogger, r Record) string {
-	return textField(c.width, fmt.Sprintf("%s(%5d)", r.Caller.File, r.Caller.Line))
+	if c.format.Link != "" && c.format.Hyperlink {
+		//pad before adding the link so escape sequences do not count in the width
+		return hyperlink(c.format.URL(r.Caller), textField(c.width, c.format.text(r.Caller)))
+	}
+	return textField(c.width, c.format.Format(r.Caller))
 }
 
 //============================================================================
@@ -177,6 +304,9 @@ type messageText struct {
 }
 
 func (c messageText) Text(l ILogger, r Record) string {
+	if r.Event != "" {
+		return textField(c.width, "event: "+r.Event)
+	}
 	return textField(c.width, r.Message)
 }
 
@@ -189,21 +319,60 @@ type dataText struct {
 
 func (c dataText) Text(l ILogger, r Record) string {
 	s := ""
-	v, ok := l.Get(c.name)
+	v, ok := recordData(l, r)[c.name]
 	if ok {
 		s = fmt.Sprintf(c.fmt, v)
 	}
 	return textField(c.width, s)
 }
 
+//============================================================================
+type fieldsText struct {
+	width int
+}
+
+func (c fieldsText) Text(l ILogger, r Record) string {
+	data := recordData(l, r)
+	names := make([]string, 0, len(data))
+	for n := range data {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	s := ""
+	for _, n := range names {
+		v := fmt.Sprintf("%v", data[n])
+		if v == "" || strings.ContainsAny(v, " =\"") {
+			v = strconv.Quote(v)
+		}
+		s += " " + n + "=" + v
+	}
+	if len(s) > 0 {
+		s = s[1:]
+	}
+	return textField(c.width, s)
+}
+
+//============================================================================
+type funcText struct {
+	fn func(ILogger, Record) string
+}
+
+func (c funcText) Text(l ILogger, r Record) string {
+	if c.fn == nil {
+		return ""
+	}
+	return c.fn(l, r)
+}
+
 //============================================================================
 func textField(w int, s string) string {
 	if w <= 0 {
 		return s
 	}
-	l := len(s)
+	//count runes rather than bytes to align labels like "✖"
+	l := utf8.RuneCountInString(s)
 	if l > w {
-		s = s[l-w:]
+		s = string([]rune(s)[l-w:])
 	}
 	return fmt.Sprintf("%-*.*s", w, w, s)
 }
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"context"
+	"encoding/binary"
+	"strconv"
+)
+
+//Datadog returns a temp logger with data "dd.trace_id" and "dd.span_id"
+//set from the span in the context (see SpanFromContext()), so that Datadog
+//can correlate the log records with the trace. If there is no span in
+//the context, the logger is returned unchanged.
+func Datadog(l ILogger, ctx context.Context) ILogger {
+	sc, ok := SpanFromContext(ctx)
+	if !ok {
+		return l
+	}
+	ll, ok := l.(*logger)
+	if !ok {
+		return l
+	}
+	sub := ll.anon(ll.group)
+	traceID, spanID := DatadogIDs(sc)
+	sub.data["dd.trace_id"] = traceID
+	sub.data["dd.span_id"] = spanID
+	return sub
+} //Datadog()
+
+//DatadogIDs converts the span to the decimal ids used by Datadog,
+//which is the lower 64 bits of the 128 bit trace id
+func DatadogIDs(sc SpanContext) (traceID, spanID string) {
+	traceID = strconv.FormatUint(binary.BigEndian.Uint64(sc.TraceID[8:]), 10)
+	spanID = strconv.FormatUint(binary.BigEndian.Uint64(sc.SpanID[:]), 10)
+	return traceID, spanID
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+//ErrWriteTimeout is returned by a deadline writer when a write was dropped
+var ErrWriteTimeout = errors.New("write timeout")
+
+//NewDeadlineWriter drops writes to w that do not complete within timeout,
+//returning ErrWriteTimeout and counting them as drops in SinkStats(), so
+//that a hung sink does not block every goroutine that logs. When w is a
+//net.Conn, its write deadline is set for each write. Other writers are
+//written by a goroutine that the caller stops waiting for after timeout,
+//and further writes are dropped immediately while that write is still
+//blocked, so that records are never written out of order. The write that
+//timed out may still complete when the sink recovers. Records are passed on
+//with WriteRecord when w implements IRecordWriter.
+//
+//A write to a net.Conn that timed out may have sent part of the record, so
+//a line or length-prefixed frame can be cut off on the stream, and after a
+//deadline expired a tls.Conn fails every later write. The connection is not
+//re-dialled here: close it after ErrWriteTimeout and create a new writer,
+//or use a writer that reconnects, e.g. NewSyslogWriter().
+func NewDeadlineWriter(w io.Writer, timeout time.Duration) io.WriteCloser {
+	dw := &deadlineWriter{w: w, timeout: timeout}
+	dw.conn, _ = w.(net.Conn)
+	return dw
+}
+
+type deadlineWriter struct {
+	mutex   sync.Mutex
+	w       io.Writer
+	conn    net.Conn
+	timeout time.Duration
+	//pending receives the result of a write that timed out, nil when none
+	pending chan deadlineResult
+}
+
+type deadlineResult struct {
+	n   int
+	err error
+}
+
+//Write is used for output without a record
+func (dw *deadlineWriter) Write(p []byte) (int, error) {
+	return dw.write(p, func(w io.Writer, p []byte) (int, error) {
+		return w.Write(p)
+	})
+}
+
+func (dw *deadlineWriter) WriteRecord(l ILogger, r Record, encoded []byte) (int, error) {
+	return dw.write(encoded, func(w io.Writer, p []byte) (int, error) {
+		return writeRecord(w, l, r, p)
+	})
+}
+
+//write calls write(w, p), giving up after the timeout
+func (dw *deadlineWriter) write(p []byte, write func(w io.Writer, p []byte) (int, error)) (int, error) {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	if dw.conn != nil {
+		dw.conn.SetWriteDeadline(time.Now().Add(dw.timeout))
+		n, err := write(dw.w, p)
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			statsOf(dw).drop()
+			return n, ErrWriteTimeout
+		}
+		return n, err
+	}
+	if dw.pending != nil {
+		select {
+		case <-dw.pending:
+			dw.pending = nil
+		default:
+			statsOf(dw).drop()
+			return 0, ErrWriteTimeout
+		}
+	}
+	//the write may continue after this returns, so it needs its own copy
+	buf := append([]byte(nil), p...)
+	result := make(chan deadlineResult, 1)
+	go func() {
+		n, err := write(dw.w, buf)
+		result <- deadlineResult{n: n, err: err}
+	}()
+	timer := time.NewTimer(dw.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-result:
+		return r.n, r.err
+	case <-timer.C:
+		dw.pending = result
+		statsOf(dw).drop()
+		return 0, ErrWriteTimeout
+	}
+} //deadlineWriter.write()
+
+//Flush flushes w if it buffers output
+func (dw *deadlineWriter) Flush() error {
+	if f, ok := dw.w.(IFlusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+//Close closes w if it can be closed
+func (dw *deadlineWriter) Close() error {
+	if c, ok := dw.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineWriterForwardsRecords(t *testing.T) {
+	remote := &remoteWriter{}
+	dw := NewDeadlineWriter(remote, time.Second)
+	l := Top().Temp("xtest").WithWriter(dw)
+	l.Warnf("slow")
+	if len(remote.records) != 1 || remote.records[0].Level != WarnLevel {
+		t.Fatalf("records %+v, want one warning", remote.records)
+	}
+}
+
+func TestDeadlineWriterDropsWhileBlocked(t *testing.T) {
+	hold := make(chan struct{})
+	remote := &remoteWriter{hold: hold}
+	dw := NewDeadlineWriter(remote, 10*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := dw.Write([]byte("x\n")); err != ErrWriteTimeout {
+			t.Fatalf("write %d: err = %v, want %v", i, err, ErrWriteTimeout)
+		}
+	}
+	remote.set(false, nil)
+	close(hold)
+	//the timed out write completes, then writes go through again
+	time.Sleep(10 * time.Millisecond)
+	if _, err := dw.Write([]byte("y\n")); err != nil {
+		t.Fatal(err)
+	}
+	remote.mutex.Lock()
+	defer remote.mutex.Unlock()
+	if len(remote.lines) != 2 || remote.lines[0] != "x\n" || remote.lines[1] != "y\n" {
+		t.Fatalf("lines %q", remote.lines)
+	}
+}
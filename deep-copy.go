@@ -0,0 +1,117 @@
+package log
+
+import (
+	"reflect"
+)
+
+//DeepCopy returns a copy of v with all maps, slices, arrays and pointers
+//copied, so that changes the caller makes to v afterwards do not change
+//what is encoded, e.g. l.With("req", log.DeepCopy(req)) or
+//Field{Name: "items", Value: log.DeepCopy(items)}. Unexported struct
+//fields, channels and functions are not copied.
+func DeepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	c := deepCopy(reflect.ValueOf(v), map[copyKey]reflect.Value{})
+	return c.Interface()
+}
+
+//copyKey identifies a pointer, map or slice that was copied, with the type
+//because a struct and its first field have the same address, and the
+//length because slices of different length can share an array
+type copyKey struct {
+	t reflect.Type
+	p uintptr
+	n int
+}
+
+//deepCopy copies v, copied holds the copies made of pointers, maps and
+//slices so that shared and cyclic values are copied only once
+func deepCopy(v reflect.Value, copied map[copyKey]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		key := copyKey{t: v.Type(), p: v.Pointer()}
+		if c, ok := copied[key]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		copied[key] = c
+		c.Elem().Set(deepCopy(v.Elem(), copied))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem(), copied))
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		key := copyKey{t: v.Type(), p: v.Pointer()}
+		if c, ok := copied[key]; ok {
+			return c
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		copied[key] = c
+		for _, k := range v.MapKeys() {
+			c.SetMapIndex(k, deepCopy(v.MapIndex(k), copied))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		key := copyKey{t: v.Type(), p: v.Pointer(), n: v.Len()}
+		if c, ok := copied[key]; ok {
+			return c
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		copied[key] = c
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), copied))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), copied))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v) //unexported fields keep their values
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i), copied))
+			}
+		}
+		return c
+	}
+	return v
+} //deepCopy()
+
+func (l *logger) SetDeepCopy(on bool) {
+	l.deepCopy = on
+	for _, ll := range l.subs {
+		ll.WithDeepCopy(on)
+	}
+}
+
+func (l *logger) WithDeepCopy(on bool) ILogger {
+	l.SetDeepCopy(on)
+	return l
+}
+
+//copyValue returns a deep copy of v when the logger copies values
+func (l *logger) copyValue(v interface{}) interface{} {
+	if !l.deepCopy {
+		return v
+	}
+	return DeepCopy(v)
+}
@@ -0,0 +1,60 @@
+package log
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeepCopy(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+	cyclicMap := map[string]interface{}{"a": 1}
+	cyclicMap["self"] = cyclicMap
+	cyclicSlice := []interface{}{1, nil}
+	cyclicSlice[1] = cyclicSlice
+	cyclicNode := &node{Name: "a"}
+	cyclicNode.Next = cyclicNode
+
+	tests := []struct {
+		name  string
+		value interface{}
+		check func(t *testing.T, c interface{})
+	}{
+		{"cyclic map", cyclicMap, func(t *testing.T, c interface{}) {
+			m := c.(map[string]interface{})
+			if reflect.ValueOf(m).Pointer() == reflect.ValueOf(cyclicMap).Pointer() {
+				t.Fatal("map not copied")
+			}
+			if reflect.ValueOf(m["self"]).Pointer() != reflect.ValueOf(m).Pointer() {
+				t.Fatal("cycle not kept in copy")
+			}
+		}},
+		{"cyclic slice", cyclicSlice, func(t *testing.T, c interface{}) {
+			s := c.([]interface{})
+			if &s[0] == &cyclicSlice[0] {
+				t.Fatal("slice not copied")
+			}
+			if inner := s[1].([]interface{}); &inner[0] != &s[0] {
+				t.Fatal("cycle not kept in copy")
+			}
+		}},
+		{"cyclic pointer", cyclicNode, func(t *testing.T, c interface{}) {
+			n := c.(*node)
+			if n == cyclicNode || n.Next != n {
+				t.Fatal("pointer cycle not copied")
+			}
+		}},
+		{"changed after copy", []int{1, 2, 3}, func(t *testing.T, c interface{}) {
+			if !reflect.DeepEqual(c, []int{1, 2, 3}) {
+				t.Fatalf("copy = %v", c)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, DeepCopy(tt.value))
+		})
+	}
+}
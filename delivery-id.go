@@ -0,0 +1,41 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+)
+
+//deliveryPrefix makes delivery ids unique across processes
+var (
+	deliveryPrefix = newDeliveryPrefix()
+	deliverySeq    uint64
+)
+
+func newDeliveryPrefix() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+//DeliveryIDs wraps an encoder to add a unique "delivery_id" to each record,
+//so that consumers can remove duplicates of records that were delivered
+//more than once, e.g. by NewAckedSpoolWriter()
+func DeliveryIDs(e IEncoder) IEncoder {
+	return deliveryIDEncoder{encoder: e}
+}
+
+type deliveryIDEncoder struct {
+	encoder IEncoder
+}
+
+func (e deliveryIDEncoder) Encode(l ILogger, r Record) []byte {
+	id := strconv.FormatUint(atomic.AddUint64(&deliverySeq, 1), 10)
+	if !isDeterministic() {
+		id = deliveryPrefix + "-" + id
+	}
+	return e.encoder.Encode(l, r.withStage(func(data map[string]interface{}) {
+		data["delivery_id"] = id
+	}))
+}
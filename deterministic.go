@@ -0,0 +1,57 @@
+package log
+
+import (
+	"path"
+	"sync/atomic"
+	"time"
+)
+
+//DeterministicTime is the time of all records in deterministic mode
+var DeterministicTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var deterministic int32
+
+//SetDeterministic makes output repeatable so that it can be compared to
+//golden files byte for byte in tests: all records have DeterministicTime,
+//which is also the epoch of ElapsedText(), measured durations are 0, caller
+//files are written without directories, delivery ids are numbered from 1
+//without a process prefix and the syslog process id is written as "-"
+//Time based limits like SetFloodGuard() and Every() still use the real
+//clock, because all records have the same time.
+func SetDeterministic(on bool) {
+	v := int32(0)
+	if on {
+		v = 1
+		atomic.StoreUint64(&deliverySeq, 0)
+	}
+	atomic.StoreInt32(&deterministic, v)
+}
+
+func isDeterministic() bool {
+	return atomic.LoadInt32(&deterministic) == 1
+}
+
+//now returns the time for a new record
+func now() time.Time {
+	if isDeterministic() {
+		return DeterministicTime
+	}
+	return time.Now()
+}
+
+//since returns the duration since start, which is 0 in deterministic mode
+func since(start time.Time) time.Duration {
+	if isDeterministic() {
+		return 0
+	}
+	return time.Since(start)
+}
+
+//normalizeCaller removes the machine dependent parts of the caller
+func normalizeCaller(c Caller) Caller {
+	if isDeterministic() {
+		c.File = path.Base(c.File)
+		c.Path = c.File
+	}
+	return c
+}
@@ -0,0 +1,32 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeterministic(t *testing.T) {
+	SetDeterministic(true)
+	defer SetDeterministic(false)
+	SetEpoch(time.Now())
+
+	if text := ElapsedText(0).Text(Top(), Record{Time: now()}); text != "0000.000s" {
+		t.Fatalf("ElapsedText() = %q", text)
+	}
+	if d := since(time.Now().Add(-time.Hour)); d != 0 {
+		t.Fatalf("since() = %v", d)
+	}
+
+	//Every() uses the real clock although all records have the same time
+	buf := bytes.NewBuffer(nil)
+	l := Top().Temp("deterministictest").WithWriter(buf)
+	for i := 0; i < 2; i++ {
+		l.Every(10 * time.Millisecond).Info("tick")
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := strings.Count(buf.String(), "tick"); n != 2 {
+		t.Fatalf("logged %d ticks: %q", n, buf.String())
+	}
+}
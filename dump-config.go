@@ -0,0 +1,79 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+)
+
+//DumpConfig writes the effective configuration of this logger and all
+//its children as a table, e.g. Top().DumpConfig(os.Stdout) to see what
+//the process is actually configured to log
+func (l *logger) DumpConfig(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "LOGGER\tLEVEL\tENCODER\tWRITER\tDATA\n")
+	l.walk(func(l *logger) {
+		name := l.Name()
+		if name == "" {
+			name = "/"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%v\n",
+			name,
+			l.level,
+			encoderIdentity(l.encoder),
+			writerIdentity(l.writer),
+			l.dataKeys())
+	})
+	tw.Flush()
+} //logger.DumpConfig()
+
+//dataKeys returns the sorted names of all data values visible
+//in this logger, i.e. its own and those inherited from parents
+func (l *logger) dataKeys() []string {
+	data := l.Data()
+	keys := make([]string, 0, len(data))
+	for n := range data {
+		keys = append(keys, n)
+	}
+	sort.Strings(keys)
+	return keys
+} //logger.dataKeys()
+
+//sortedSubs returns the children of this logger sorted by name
+func (l *logger) sortedSubs() []ILogger {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	names := make([]string, 0, len(l.subs))
+	for n := range l.subs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	subs := make([]ILogger, 0, len(names))
+	for _, n := range names {
+		subs = append(subs, l.subs[n])
+	}
+	return subs
+} //logger.sortedSubs()
+
+func encoderIdentity(e IEncoder) string {
+	if e == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%T", e)
+}
+
+func writerIdentity(w io.Writer) string {
+	if w == nil {
+		return "none"
+	}
+	if f, ok := w.(*os.File); ok {
+		return f.Name()
+	}
+	if reflect.ValueOf(w).Kind() == reflect.Ptr {
+		return fmt.Sprintf("%T(%p)", w, w)
+	}
+	return fmt.Sprintf("%T", w)
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	epochMutex sync.Mutex
+	epoch      = time.Now()
+)
+
+//SetEpoch sets the time from which ElapsedText() is measured,
+//which is the start of the process by default
+func SetEpoch(t time.Time) {
+	epochMutex.Lock()
+	defer epochMutex.Unlock()
+	epoch = t
+}
+
+//Epoch returns the time from which ElapsedText() is measured
+func Epoch() time.Time {
+	epochMutex.Lock()
+	defer epochMutex.Unlock()
+	return epoch
+}
+
+//ElapsedText writes the time since the epoch (see SetEpoch()) in seconds,
+//e.g. "0012.345s", which is easier to read than the time of day when
+//looking at a sequence of events such as program startup
+func ElapsedText(width int) ITextValue {
+	return elapsedText{width: width}
+}
+
+//============================================================================
+type elapsedText struct {
+	width int
+}
+
+func (c elapsedText) Text(l ILogger, r Record) string {
+	epoch := Epoch()
+	if isDeterministic() {
+		epoch = DeterministicTime
+	}
+	elapsed := r.Time.Sub(epoch)
+	return textField(c.width, fmt.Sprintf("%08.3fs", elapsed.Seconds()))
+}
+
+//DeltaText writes the time since the previous record written to the same
+//writer, e.g. "+000.012s", to see gaps between consecutive operations
+func DeltaText(width int) ITextValue {
+	return &deltaText{
+		width: width,
+		last:  map[interface{}]time.Time{},
+	}
+}
+
+//============================================================================
+type deltaText struct {
+	width int
+	mutex sync.Mutex
+	last  map[interface{}]time.Time
+}
+
+func (c *deltaText) Text(l ILogger, r Record) string {
+	var key interface{}
+	if w := l.Writer(); w != nil && reflect.TypeOf(w).Comparable() {
+		key = w
+	}
+	c.mutex.Lock()
+	last, ok := c.last[key]
+	c.last[key] = r.Time
+	c.mutex.Unlock()
+	if !ok {
+		return textField(c.width, "")
+	}
+	return textField(c.width, fmt.Sprintf("+%07.3fs", r.Time.Sub(last).Seconds()))
+}
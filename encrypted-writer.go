@@ -0,0 +1,225 @@
+package log
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+//encrypted output consists of chunks, one per write, each with 4 bytes
+//length (big endian) of the rest of the chunk, the salt of the writer, a
+//nonce and the AES-GCM sealed data, so that a file can be appended to after
+//a restart. Each writer encrypts with its own key derived from the key and a
+//random salt, and uses a counter as nonce, so that nonces never repeat under
+//a key however long the key is used. The counter starts at 0 for each salt,
+//so the reader detects missing, repeated and reordered chunks. Close writes
+//an empty final chunk with encryptedFinal in the first byte of the nonce,
+//so that the reader also detects output cut after a chunk.
+const encryptedSaltSize = 16
+
+const encryptedFinal = 1
+
+//maxEncryptedChunk limits the size read for one chunk, larger writes are
+//split into more chunks
+const maxEncryptedChunk = 64 << 20
+
+//NewEncryptedWriter encrypts each write to w with AES-GCM, for logs with
+//regulated data that must not sit on disk in plaintext. The key must be
+//16, 24 or 32 bytes for AES-128, AES-192 or AES-256.
+//Use NewDecryptReader() to read the logs, and Close the writer so that the
+//reader can tell that the output is complete.
+//Only symmetric keys are supported: there is no recipient (public key) mode
+//as in age, so the key must be available to both the writer and the reader.
+func NewEncryptedWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	if _, err := newAEAD(key); err != nil {
+		return nil, err
+	}
+	salt := make([]byte, encryptedSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("cannot generate salt: %v", err)
+	}
+	aead, err := newAEAD(deriveKey(key, salt))
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedWriter{w: w, aead: aead, salt: salt}, nil
+}
+
+//encryptedWriter implements io.WriteCloser and IFlusher
+type encryptedWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+	aead  cipher.AEAD
+	salt  []byte
+	//counter is the nonce of the next chunk
+	counter uint64
+	closed  bool
+}
+
+func (ew *encryptedWriter) Write(p []byte) (int, error) {
+	ew.mutex.Lock()
+	defer ew.mutex.Unlock()
+	if ew.closed {
+		return 0, fmt.Errorf("encrypted writer is closed")
+	}
+	max := maxEncryptedChunk - encryptedSaltSize - ew.aead.NonceSize() - ew.aead.Overhead()
+	written := 0
+	for len(p) > 0 {
+		part := p
+		if len(part) > max {
+			part = part[:max]
+		}
+		if err := ew.writeChunk(part, 0); err != nil {
+			return written, err
+		}
+		written += len(part)
+		p = p[len(part):]
+	}
+	return written, nil
+} //encryptedWriter.Write()
+
+//writeChunk encrypts p as the next chunk, the caller must hold the mutex
+func (ew *encryptedWriter) writeChunk(p []byte, flags byte) error {
+	nonceSize := ew.aead.NonceSize()
+	chunk := make([]byte, 4+encryptedSaltSize+nonceSize, 4+encryptedSaltSize+nonceSize+len(p)+ew.aead.Overhead())
+	copy(chunk[4:], ew.salt)
+	nonce := chunk[4+encryptedSaltSize:]
+	nonce[0] = flags
+	binary.BigEndian.PutUint64(nonce[nonceSize-8:], ew.counter)
+	ew.counter++
+	chunk = ew.aead.Seal(chunk, nonce, p, nil)
+	binary.BigEndian.PutUint32(chunk[0:4], uint32(len(chunk)-4))
+	_, err := ew.w.Write(chunk)
+	return err
+}
+
+//Flush flushes w if it buffers output
+func (ew *encryptedWriter) Flush() error {
+	if f, ok := ew.w.(IFlusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+//Close writes the final chunk and closes w if it can be closed
+func (ew *encryptedWriter) Close() error {
+	ew.mutex.Lock()
+	defer ew.mutex.Unlock()
+	if ew.closed {
+		return nil
+	}
+	ew.closed = true
+	err := ew.writeChunk(nil, encryptedFinal)
+	if c, ok := ew.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+//NewDecryptReader returns a reader of the plaintext written with
+//NewEncryptedWriter() using the same key. Read fails if the data was
+//modified, chunks are missing, repeated or reordered, or the key is wrong.
+//It also fails at the end when the last writer was not closed, i.e. the
+//output was cut or is still being written. A writer that stopped without
+//Close and was followed by another one, as after a crash, is accepted.
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{r: r, key: key, aead: aead, salts: map[string]bool{}}, nil
+}
+
+//decryptReader implements io.Reader
+type decryptReader struct {
+	r   io.Reader
+	key []byte
+	//aead is for the key derived with salt
+	aead  cipher.AEAD
+	salt  []byte
+	plain []byte
+	//next is the expected counter of the next chunk with salt
+	next uint64
+	//final is true after the final chunk of salt
+	final bool
+	//salts were used by earlier writers
+	salts map[string]bool
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.plain) == 0 {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(dr.r, header); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return 0, fmt.Errorf("truncated chunk header")
+			}
+			if err == io.EOF && dr.salt != nil && !dr.final {
+				return 0, fmt.Errorf("truncated after chunk %d: writer was not closed", dr.next-1)
+			}
+			return 0, err
+		}
+		size := binary.BigEndian.Uint32(header)
+		if size < uint32(encryptedSaltSize+dr.aead.NonceSize()) || size > maxEncryptedChunk {
+			return 0, fmt.Errorf("invalid chunk size %d", size)
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(dr.r, chunk); err != nil {
+			return 0, fmt.Errorf("truncated chunk: %v", err)
+		}
+		salt, chunk := chunk[:encryptedSaltSize], chunk[encryptedSaltSize:]
+		if !bytes.Equal(salt, dr.salt) {
+			//a new writer appended to the file
+			if dr.salts[string(salt)] {
+				return 0, fmt.Errorf("chunk of an earlier writer out of order")
+			}
+			aead, err := newAEAD(deriveKey(dr.key, salt))
+			if err != nil {
+				return 0, err
+			}
+			dr.aead, dr.salt, dr.next, dr.final = aead, salt, 0, false
+			dr.salts[string(salt)] = true
+		} else if dr.final {
+			return 0, fmt.Errorf("chunk after the final chunk")
+		}
+		nonceSize := dr.aead.NonceSize()
+		nonce, sealed := chunk[:nonceSize], chunk[nonceSize:]
+		plain, err := dr.aead.Open(sealed[:0], nonce, sealed, nil)
+		if err != nil {
+			return 0, fmt.Errorf("cannot decrypt chunk: %v", err)
+		}
+		//decryption fails with any other nonce, so its counter and flags can be trusted
+		if counter := binary.BigEndian.Uint64(nonce[nonceSize-8:]); counter != dr.next {
+			return 0, fmt.Errorf("chunk %d where chunk %d was expected", counter, dr.next)
+		}
+		dr.next++
+		dr.final = nonce[0] == encryptedFinal
+		dr.plain = plain
+	}
+	n := copy(p, dr.plain)
+	dr.plain = dr.plain[n:]
+	return n, nil
+} //decryptReader.Read()
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+//deriveKey returns the key of a writer, of the same size as key
+func deriveKey(key, salt []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(salt)
+	return mac.Sum(nil)[:len(key)]
+}
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"testing"
+)
+
+func TestEncryptedWriterRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	buf := bytes.NewBuffer(nil)
+	//two writers append to the same output, as after a restart
+	for _, records := range [][]string{{"one\n", "two\n"}, {"three\n"}} {
+		w, err := NewEncryptedWriter(buf, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range records {
+			if _, err := w.Write([]byte(r)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.Close()
+	}
+	if bytes.Contains(buf.Bytes(), []byte("two")) {
+		t.Fatal("plaintext in encrypted output")
+	}
+	encrypted := buf.Bytes()
+
+	tests := []struct {
+		name    string
+		key     []byte
+		modify  func(b []byte) []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", key: key, want: "one\ntwo\nthree\n"},
+		{name: "wrong key", key: bytes.Repeat([]byte{8}, 32), wantErr: true},
+		{name: "flipped bit", key: key, modify: func(b []byte) []byte { b[len(b)-1] ^= 1; return b }, want: "one\ntwo\nthree\n", wantErr: true},
+		{name: "changed salt", key: key, modify: func(b []byte) []byte { b[4] ^= 1; return b }, wantErr: true},
+		{name: "truncated", key: key, modify: func(b []byte) []byte { return b[:len(b)-3] }, want: "one\ntwo\nthree\n", wantErr: true},
+		{name: "huge chunk size", key: key, modify: func(b []byte) []byte { b[0] = 0xff; return b }, wantErr: true},
+		{name: "dropped chunk", key: key, modify: func(b []byte) []byte { return joinChunks(chunks(b), 1) }, want: "one\n", wantErr: true},
+		{name: "duplicated chunk", key: key, modify: func(b []byte) []byte {
+			c := chunks(b)
+			return joinChunks(append(c[:1:1], c...))
+		}, want: "one\n", wantErr: true},
+		{name: "reordered chunks", key: key, modify: func(b []byte) []byte {
+			c := chunks(b)
+			c[0], c[1] = c[1], c[0]
+			return joinChunks(c)
+		}, wantErr: true},
+		{name: "earlier writer repeated", key: key, modify: func(b []byte) []byte {
+			c := chunks(b)
+			return joinChunks(append(c, c[:3]...))
+		}, want: "one\ntwo\nthree\n", wantErr: true},
+		{name: "cut at chunk boundary", key: key, modify: func(b []byte) []byte {
+			c := chunks(b)
+			return joinChunks(c[:len(c)-1])
+		}, want: "one\ntwo\nthree\n", wantErr: true},
+		{name: "writer not closed before restart", key: key, modify: func(b []byte) []byte { return joinChunks(chunks(b), 2) }, want: "one\ntwo\nthree\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append([]byte(nil), encrypted...)
+			if tt.modify != nil {
+				data = tt.modify(data)
+			}
+			r, err := NewDecryptReader(bytes.NewReader(data), tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plain, err := ioutil.ReadAll(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if string(plain) != tt.want {
+				t.Fatalf("plain = %q, want %q", plain, tt.want)
+			}
+		})
+	}
+}
+
+//chunks splits encrypted output into its chunks
+func chunks(b []byte) [][]byte {
+	c := [][]byte{}
+	for len(b) >= 4 {
+		n := 4 + int(binary.BigEndian.Uint32(b))
+		c = append(c, b[:n])
+		b = b[n:]
+	}
+	return c
+}
+
+//joinChunks joins the chunks except those with the skipped indexes
+func joinChunks(c [][]byte, skip ...int) []byte {
+	b := []byte{}
+	for i := range c {
+		skipped := false
+		for _, s := range skip {
+			skipped = skipped || s == i
+		}
+		if !skipped {
+			b = append(b, c[i]...)
+		}
+	}
+	return b
+}
+
+func TestEncryptedWriterSplitsLargeWrites(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	buf := bytes.NewBuffer(nil)
+	w, _ := NewEncryptedWriter(buf, key)
+	large := bytes.Repeat([]byte("x"), maxEncryptedChunk+100)
+	if n, err := w.Write(large); err != nil || n != len(large) {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	w.Close()
+	if n := len(chunks(buf.Bytes())); n != 3 {
+		t.Fatalf("wrote %d chunks, want 2 and the final chunk", n)
+	}
+	r, _ := NewDecryptReader(buf, key)
+	plain, err := ioutil.ReadAll(r)
+	if err != nil || !bytes.Equal(plain, large) {
+		t.Fatalf("read %d bytes, %v", len(plain), err)
+	}
+}
+
+func TestEncryptedWriterNonces(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, _ := NewEncryptedWriter(buf, bytes.Repeat([]byte{1}, 16))
+	w.Write([]byte("a"))
+	w.Write([]byte("a"))
+	chunkSize := buf.Len() / 2
+	first, second := buf.Bytes()[:chunkSize], buf.Bytes()[chunkSize:]
+	if bytes.Equal(first, second) {
+		t.Fatal("same chunk for the same plaintext")
+	}
+	if !bytes.Equal(first[4:4+encryptedSaltSize], second[4:4+encryptedSaltSize]) {
+		t.Fatal("salt changed within one writer")
+	}
+}
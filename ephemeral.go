@@ -0,0 +1,86 @@
+package log
+
+import (
+	"sync"
+)
+
+//IEphemeral is a request-scoped logger view returned by Ephemeral()
+type IEphemeral interface {
+	ILogger
+	//Release returns the view to the pool when the request is done,
+	//it must not be used afterwards
+	Release()
+}
+
+//ephemeral implements IEphemeral
+type ephemeral struct {
+	*logger
+}
+
+var ephemeralPool = sync.Pool{
+	New: func() interface{} {
+		return &ephemeral{logger: &logger{}}
+	},
+}
+
+//Ephemeral returns a lightweight view of this logger carrying the fields
+//as data, e.g. for one request. Unlike Logger() and Temp() it does not
+//lock or change the logger tree, and the view is taken from a pool, so
+//call Release() when the request is done to reuse it.
+func (l *logger) Ephemeral(fields ...Field) IEphemeral {
+	e := ephemeralPool.Get().(*ephemeral)
+	data, subs := e.data, e.subs
+	if data == nil {
+		data = map[string]interface{}{}
+		subs = map[string]ILogger{}
+	}
+	*e.logger = logger{
+		parent:   l,
+		level:    l.level,
+		data:     data,
+		subs:     subs,
+		writer:   l.writer,
+		encoder:  l.encoder,
+		group:    l.group,
+		v:        l.v,
+		sampling: l.sampling,
+		schema:   l.schema,
+		deepCopy: l.deepCopy,
+		pooled:   true,
+	}
+	for _, f := range fields {
+		if ValidName(f.Name) && f.Value != nil {
+			data[l.key(f.Name)] = l.copyValue(f.Value)
+		}
+	}
+	return e
+} //logger.Ephemeral()
+
+//retained returns a logger with the same name, data and encoder that is
+//safe to keep after the record was logged, for writers that encode later:
+//pooled loggers are reused after Release()
+func (l *logger) retained() *logger {
+	if !l.pooled {
+		return l
+	}
+	return &logger{
+		parent:  l.statsNode(),
+		level:   l.level,
+		data:    l.Data(),
+		subs:    map[string]ILogger{},
+		writer:  l.writer,
+		encoder: l.encoder,
+		group:   l.group,
+	}
+} //logger.retained()
+
+func (e *ephemeral) Release() {
+	for n := range e.data {
+		delete(e.data, n)
+	}
+	for n := range e.subs {
+		delete(e.subs, n)
+	}
+	e.parent = nil
+	ephemeralPool.Put(e)
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	errorHandlerMutex sync.Mutex
+	errorHandler      = defaultErrorHandler()
+)
+
+//SetErrorHandler sets the function called when encoding or writing a record
+//fails anywhere in the logger tree, which is otherwise not visible because
+//logging functions do not return errors. Set nil to restore the default,
+//which writes at most one message per second to stderr.
+func SetErrorHandler(h func(error)) {
+	if h == nil {
+		h = defaultErrorHandler()
+	}
+	errorHandlerMutex.Lock()
+	defer errorHandlerMutex.Unlock()
+	errorHandler = h
+}
+
+//handleError passes err to the error handler
+func handleError(err error) {
+	if err == nil {
+		return
+	}
+	errorHandlerMutex.Lock()
+	h := errorHandler
+	errorHandlerMutex.Unlock()
+	h(err)
+}
+
+//defaultErrorHandler writes errors to stderr, but at most once per second
+//with a count of errors that were not written since the last one
+func defaultErrorHandler() func(error) {
+	var (
+		mutex      sync.Mutex
+		last       time.Time
+		suppressed int
+	)
+	return func(err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		now := time.Now()
+		if now.Sub(last) < time.Second {
+			suppressed++
+			return
+		}
+		if suppressed > 0 {
+			fmt.Fprintf(os.Stderr, "log: %v (and %d more errors)\n", err, suppressed)
+		} else {
+			fmt.Fprintf(os.Stderr, "log: %v\n", err)
+		}
+		last = now
+		suppressed = 0
+	}
+} //defaultErrorHandler()
+
+//encode calls the encoder, reporting a panic in the encoder as an error
+func encode(e IEncoder, l ILogger, r Record) (encoded []byte, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("encoder %s failed: %v", encoderIdentity(e), p)
+		}
+	}()
+	return e.Encode(l, r), nil
+}
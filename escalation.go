@@ -0,0 +1,84 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+//EscalationRule writes an ErrorLevel record when more than Threshold
+//WarnLevel records with a message matching Pattern are written by a logger
+//within Window, so that a slow-burn problem that is only ever logged as a
+//warning still surfaces as an error, e.g. for more than 20 retries in
+//10 minutes:
+//	log.AddEscalation(log.EscalationRule{
+//		Name:      "db-retries",
+//		Logger:    "db",
+//		Pattern:   regexp.MustCompile(`^retry`),
+//		Threshold: 20,
+//		Window:    10 * time.Minute,
+//	})
+//The error record is written by the logger of the last warning with its
+//caller, the message "escalated <name>: <count> warnings in <window>:
+//<last warning>" and fields "escalation" and "warnings", prefixed with the
+//group of the logger like other fields (see WithGroup()). It is checked by
+//alert rules (see AddAlert()) like any other error.
+type EscalationRule struct {
+	Name string
+	//Logger is the name of the logger, e.g. "db", which also matches its
+	//children like "db/pool", or "" to match all loggers
+	Logger string
+	//Pattern must match the message of the warnings, nil matches all
+	Pattern   *regexp.Regexp
+	Threshold int
+	//Window is the time in which warnings are counted, a minute if not positive
+	Window time.Duration
+	//Cooldown is the time after escalating before the rule can escalate
+	//again, warnings in this time are not counted
+	Cooldown time.Duration
+	//OnAlert and WebhookURL are optional alert hooks, see AlertRule
+	OnAlert    func(Alert)
+	WebhookURL string
+}
+
+//AddEscalation adds a rule that is checked for each record written
+//and returns a func to remove it
+func AddEscalation(rule EscalationRule) (remove func()) {
+	return addAlertState(&alertState{
+		rule: AlertRule{
+			Name:       rule.Name,
+			Logger:     rule.Logger,
+			Level:      WarnLevel,
+			Threshold:  rule.Threshold,
+			Window:     rule.Window,
+			Cooldown:   rule.Cooldown,
+			OnAlert:    rule.OnAlert,
+			WebhookURL: rule.WebhookURL,
+		},
+		escalation: &rule,
+	})
+} //AddEscalation()
+
+//matches is true for warnings that count for the rule
+func (e *EscalationRule) matches(r Record) bool {
+	return r.Level == WarnLevel && (e.Pattern == nil || e.Pattern.MatchString(r.Message))
+}
+
+//escalate writes the error record for the warning r that made the rule fire
+func (e *EscalationRule) escalate(l ILogger, r Record, a Alert) {
+	ll, ok := l.(*logger)
+	if !ok || !ll.enabled(ErrorLevel) {
+		return
+	}
+	ll.write(Record{
+		Time:    now(),
+		Caller:  r.Caller,
+		Level:   ErrorLevel,
+		Message: fmt.Sprintf("escalated %s: %d warnings in %v: %s", e.Name, a.Count, a.Window, r.Message),
+		Fields: []Field{
+			{Name: ll.key("escalation"), Value: e.Name},
+			{Name: ll.key("warnings"), Value: a.Count},
+		},
+		Data: r.Data,
+	})
+} //EscalationRule.escalate()
@@ -0,0 +1,8 @@
+package log
+
+func (l *logger) Event(name string, fields ...Field) {
+	//events ignore the level, sampling and flood guard but not a discarding logger
+	if l.encoder != nil && l.writer != nil {
+		l.emit(GetCaller(3), InfoLevel, name, name, fields)
+	}
+} //logger.Event()
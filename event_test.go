@@ -0,0 +1,24 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventsAreNotDemoted(t *testing.T) {
+	SetFloodGuard(2, time.Minute)
+	defer SetFloodGuard(0, 0)
+	buf := bytes.NewBuffer(nil)
+	l := Top().Temp("eventtest").WithWriter(buf).WithEncoder(DefaultEncoder()).WithLevel(WarnLevel)
+	for i := 0; i < 10; i++ {
+		l.Event("order_placed", Field{Name: "amount", Value: i})
+	}
+	if n := strings.Count(buf.String(), "event: order_placed"); n != 10 {
+		t.Fatalf("wrote %d of 10 events:\n%s", n, buf.String())
+	}
+	if strings.Contains(buf.String(), "demoting") {
+		t.Fatalf("events were demoted:\n%s", buf.String())
+	}
+}
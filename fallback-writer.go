@@ -0,0 +1,68 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+//NewFallbackWriter writes to primary, but after maxErrors consecutive failed
+//writes it diverts output to fallback (e.g. os.Stderr) for the retryAfter
+//duration before trying primary again, so that records are not lost when
+//e.g. a network sink dies. A record that failed on primary is written to
+//fallback and a line explaining the failure is written to fallback when
+//diverting and when primary works again.
+func NewFallbackWriter(primary, fallback io.Writer, maxErrors int, retryAfter time.Duration) io.Writer {
+	if maxErrors < 1 {
+		maxErrors = 1
+	}
+	return &fallbackWriter{
+		primary:    primary,
+		fallback:   fallback,
+		maxErrors:  maxErrors,
+		retryAfter: retryAfter,
+	}
+}
+
+//fallbackWriter implements io.Writer
+type fallbackWriter struct {
+	mutex      sync.Mutex
+	primary    io.Writer
+	fallback   io.Writer
+	maxErrors  int
+	retryAfter time.Duration
+	nrErrors   int
+	divertedAt time.Time
+}
+
+func (fw *fallbackWriter) Write(p []byte) (int, error) {
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+
+	diverted := !fw.divertedAt.IsZero()
+	if diverted && time.Since(fw.divertedAt) < fw.retryAfter {
+		return fw.fallback.Write(p)
+	}
+
+	n, err := fw.primary.Write(p)
+	if err == nil {
+		if diverted {
+			fw.divertedAt = time.Time{}
+			fmt.Fprintf(fw.fallback, "log: writer %s works again, no longer writing to fallback\n", writerIdentity(fw.primary))
+		}
+		fw.nrErrors = 0
+		return n, nil
+	}
+
+	fw.nrErrors++
+	if diverted || fw.nrErrors >= fw.maxErrors {
+		if !diverted {
+			fmt.Fprintf(fw.fallback, "log: writer %s failed %d times (last error: %v), writing to fallback for %v\n",
+				writerIdentity(fw.primary), fw.nrErrors, err, fw.retryAfter)
+		}
+		fw.divertedAt = time.Now()
+	}
+	//do not lose the record that failed
+	return fw.fallback.Write(p)
+} //fallbackWriter.Write()
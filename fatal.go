@@ -0,0 +1,62 @@
+package log
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+//FatalBehavior is what happens after a record is logged with Fatal(),
+//Fatalf(), Fatalt(), FatalEvent(), FatalErr() or Must()
+//It is Exit(code), Panic or Continue.
+type FatalBehavior int
+
+const (
+	//Panic panics with a *LoggedError after logging
+	Panic FatalBehavior = -1
+	//Continue returns after logging, leaving termination to the caller
+	Continue FatalBehavior = -2
+)
+
+//Exit runs Shutdown() and exits the process with the code after logging,
+//Exit(1) is the default behavior
+func Exit(code int) FatalBehavior {
+	if code < 0 {
+		code = 1
+	}
+	return FatalBehavior(code)
+}
+
+var fatalBehavior = int32(Exit(1))
+
+//SetFatalBehavior sets what happens after logging fatal records, so that
+//libraries can log with fatal semantics while the application decides how
+//the process terminates, e.g. SetFatalBehavior(Panic) in tests
+func SetFatalBehavior(b FatalBehavior) {
+	atomic.StoreInt32(&fatalBehavior, int32(b))
+}
+
+//fatal applies the fatal behavior after a fatal record was logged
+//it does nothing for loggers that discard records, e.g. from IfError(nil)
+//or V(n), so that those remain a no-op
+func (l *logger) fatal(msg string, err error) {
+	if !l.enabled(FatalLevel) {
+		return
+	}
+	switch b := FatalBehavior(atomic.LoadInt32(&fatalBehavior)); b {
+	case Continue:
+		return
+	case Panic:
+		panic(&LoggedError{Msg: msg, Err: err, Data: l.Data()})
+	default:
+		Shutdown()
+		os.Exit(int(b))
+	}
+}
+
+func (l *logger) FatalErr(err error) {
+	if err == nil {
+		return
+	}
+	l.log(0, FatalLevel, err.Error(), Field{Name: "error", Value: err})
+	l.fatal("", err)
+}
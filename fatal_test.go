@@ -0,0 +1,56 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFatalOnDiscardingLoggerIsNoop(t *testing.T) {
+	SetFatalBehavior(Panic)
+	defer SetFatalBehavior(Exit(1))
+	buf := bytes.NewBuffer(nil)
+	l := Top().Temp("fataltest").WithWriter(buf)
+
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"IfError(nil).Fatalf", func() { l.IfError(nil).Fatalf("x %d", 1) }},
+		{"IfTrue(false).Fatal", func() { l.IfTrue(false).Fatal("x") }},
+		{"V(9).Fatal", func() { l.V(9).Fatal("x") }},
+		{"V(9).Fatalt", func() { l.V(9).Fatalt("x {n}", 1) }},
+		{"V(9).FatalErr", func() { l.V(9).FatalErr(errors.New("x")) }},
+		{"V(9).Must", func() { l.V(9).Must(errors.New("x")) }},
+		{"V(9).FatalEvent", func() { l.V(9).FatalEvent().Str("a", "b").Msg("x") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if p := recover(); p != nil {
+					t.Fatalf("unexpected fatal behavior: %v", p)
+				}
+			}()
+			tt.fn()
+		})
+	}
+	if buf.Len() > 0 {
+		t.Fatalf("discarding loggers wrote %q", buf.String())
+	}
+}
+
+func TestFatalAppliesBehavior(t *testing.T) {
+	SetFatalBehavior(Panic)
+	defer SetFatalBehavior(Exit(1))
+	buf := bytes.NewBuffer(nil)
+	l := Top().Temp("fataltest").WithWriter(buf)
+	defer func() {
+		if _, ok := recover().(*LoggedError); !ok {
+			t.Fatalf("Fatal() did not panic with *LoggedError")
+		}
+		if buf.Len() == 0 {
+			t.Fatalf("Fatal() wrote nothing")
+		}
+	}()
+	l.Fatal("stop")
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"path"
+)
+
+//FieldFilter selects data values by name using path.Match patterns,
+//e.g. Deny: []string{"sql", "http.body*"}
+type FieldFilter struct {
+	//Allow lists the names to write, all names when empty
+	Allow []string
+	//Deny lists the names not to write, even when allowed
+	Deny []string
+}
+
+//Allowed returns true if data value n passes the filter
+func (f FieldFilter) Allowed(n string) bool {
+	if len(f.Allow) > 0 && !matchAny(f.Allow, n) {
+		return false
+	}
+	return !matchAny(f.Deny, n)
+}
+
+func matchAny(patterns []string, n string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(p, n); ok || (err != nil && p == n) {
+			return true
+		}
+	}
+	return false
+}
+
+//FilterFields wraps an encoder to write only the data values that pass
+//the filter, so that e.g. the console gets everything while an external
+//shipper drops internal fields like request bodies and SQL text, using
+//the encoder for one sink of NewMultiWriter():
+//	shipper := log.FilterFields(log.NewJSONEncoder(), log.FieldFilter{Deny: []string{"sql", "*.body"}})
+func FilterFields(e IEncoder, f FieldFilter) IEncoder {
+	return fieldFilterEncoder{encoder: e, filter: f}
+}
+
+type fieldFilterEncoder struct {
+	encoder IEncoder
+	filter  FieldFilter
+}
+
+func (e fieldFilterEncoder) Encode(l ILogger, r Record) []byte {
+	return e.encoder.Encode(l, r.withStage(func(data map[string]interface{}) {
+		for n := range data {
+			if !e.filter.Allowed(n) {
+				delete(data, n)
+			}
+		}
+	}))
+}
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	floodMutex     sync.Mutex
+	floodThreshold int
+	floodDuration  time.Duration
+	floodSites     sync.Map
+)
+
+//SetFloodGuard enables demotion of call sites that log more than threshold
+//records in one second: their records are logged one level lower (e.g. info
+//as debug) for the duration, with a one-time warning when that starts, so
+//that a runaway loop does not flood the output. Errors, more important
+//records and events (see ILogger.Event()) are never demoted.
+//A threshold of 0 disables the guard.
+func SetFloodGuard(threshold int, duration time.Duration) {
+	floodMutex.Lock()
+	defer floodMutex.Unlock()
+	floodThreshold = threshold
+	floodDuration = duration
+}
+
+//floodSite is the state of one call site
+type floodSite struct {
+	mutex        sync.Mutex
+	second       int64
+	count        int
+	demotedUntil time.Time
+}
+
+//floodGuard demotes the record if its call site is flooding and
+//returns false if the demoted record must not be logged
+func (l *logger) floodGuard(r *Record) bool {
+	floodMutex.Lock()
+	threshold, duration := floodThreshold, floodDuration
+	floodMutex.Unlock()
+	if threshold <= 0 || r.Level >= ErrorLevel || r.Event != "" {
+		return true
+	}
+
+	s, _ := floodSites.LoadOrStore(r.Caller.PC, &floodSite{})
+	site := s.(*floodSite)
+	//not r.Time, which is the same for all records in deterministic mode
+	t := time.Now()
+	site.mutex.Lock()
+	sec := t.Unix()
+	if site.second != sec {
+		site.second = sec
+		site.count = 0
+	}
+	site.count++
+	demoted := t.Before(site.demotedUntil)
+	startDemotion := !demoted && site.count > threshold
+	if startDemotion {
+		site.demotedUntil = t.Add(duration)
+		demoted = true
+	}
+	site.mutex.Unlock()
+
+	if startDemotion {
+		notice := *r
+		notice.Level = WarnLevel
+		notice.Fields = nil
+		notice.Message = fmt.Sprintf("call site logged more than %d records per second, demoting its records by one level for %v", threshold, duration)
+		l.write(notice)
+	}
+	if demoted {
+		r.Level = demote(r.Level)
+		return r.Level >= l.level
+	}
+	return true
+} //logger.floodGuard()
+
+//demote returns the next less important level
+func demote(level Level) Level {
+	switch level {
+	case DebugLevel:
+		return TraceLevel
+	case TraceLevel:
+		return TraceLevel
+	}
+	return level - 1
+}
@@ -1,3 +1,3 @@
 module github.com/go-msvc/log
 
-go 1.12
+go 1.18
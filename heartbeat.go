@@ -0,0 +1,69 @@
+package log
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+//processStart is used for the uptime in heartbeats
+var processStart = time.Now()
+
+//StartHeartbeat writes a "heartbeat" event (see ILogger.Event()) now and
+//then every interval until stop is called, with data about the state of
+//the process:
+//	uptime_s      seconds since the process started
+//	goroutines    nr of goroutines
+//	heap_alloc    bytes allocated on the heap and not yet freed
+//	heap_objects  nr of objects allocated on the heap
+//	sys           bytes obtained from the OS
+//	num_gc        nr of garbage collections
+//	gc_pause_ms   total time spent in garbage collection pauses
+//so that it is clear from the logs alone that the process is alive, e.g.
+//	stop := log.StartHeartbeat(log.Logger("app"), time.Minute)
+//	defer stop()
+//An interval that is not positive is reported (see SetErrorHandler()) and
+//no heartbeat is written.
+func StartHeartbeat(l ILogger, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		handleError(fmt.Errorf("invalid heartbeat interval %v", interval))
+		return func() {}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			l.Event("heartbeat", heartbeatFields()...)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+} //StartHeartbeat()
+
+func heartbeatFields() []Field {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return []Field{
+		{Name: "uptime_s", Value: int64(since(processStart) / time.Second)},
+		{Name: "goroutines", Value: runtime.NumGoroutine()},
+		{Name: "heap_alloc", Value: m.HeapAlloc},
+		{Name: "heap_objects", Value: m.HeapObjects},
+		{Name: "sys", Value: m.Sys},
+		{Name: "num_gc", Value: m.NumGC},
+		{Name: "gc_pause_ms", Value: m.PauseTotalNs / uint64(time.Millisecond)},
+	}
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartHeartbeat(t *testing.T) {
+	var reported []error
+	SetErrorHandler(func(err error) { reported = append(reported, err) })
+	defer SetErrorHandler(nil)
+
+	tests := []struct {
+		name       string
+		interval   time.Duration
+		wantBeats  bool
+		wantErrors int
+	}{
+		{"zero", 0, false, 1},
+		{"negative", -time.Second, false, 1},
+		{"positive", time.Hour, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reported = nil
+			buf := bytes.NewBuffer(nil)
+			stop := StartHeartbeat(Top().Temp("heartbeattest").WithWriter(buf), tt.interval)
+			//the first heartbeat is written before waiting for the ticker
+			stop()
+			stop()
+			if got := strings.Contains(buf.String(), "heartbeat"); got != tt.wantBeats {
+				t.Fatalf("heartbeat written %v: %q", got, buf.String())
+			}
+			if len(reported) != tt.wantErrors {
+				t.Fatalf("reported %v", reported)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package log
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+//HMACKeyRing holds the keys used to sign and verify records, indexed by id.
+//Records are signed with the current key and verified with any key in the
+//ring, so keys can be rotated without failing records still in transit.
+type HMACKeyRing struct {
+	mutex   sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+//NewHMACKeyRing returns a key ring with one key that is used for signing
+func NewHMACKeyRing(id string, key []byte) *HMACKeyRing {
+	ring := &HMACKeyRing{keys: map[string][]byte{}}
+	ring.Rotate(id, key)
+	return ring
+}
+
+//Rotate adds a key and signs with it from now on
+//the previous keys remain valid for verification until removed
+func (ring *HMACKeyRing) Rotate(id string, key []byte) {
+	ring.mutex.Lock()
+	defer ring.mutex.Unlock()
+	ring.keys[id] = append([]byte{}, key...)
+	ring.current = id
+}
+
+//Remove a key so records signed with it no longer verify
+//the current key cannot be removed
+func (ring *HMACKeyRing) Remove(id string) {
+	ring.mutex.Lock()
+	defer ring.mutex.Unlock()
+	if id != ring.current {
+		delete(ring.keys, id)
+	}
+}
+
+func (ring *HMACKeyRing) sign(record []byte) (string, []byte) {
+	ring.mutex.RLock()
+	defer ring.mutex.RUnlock()
+	return ring.current, hmacSum(ring.keys[ring.current], record)
+}
+
+func (ring *HMACKeyRing) key(id string) ([]byte, bool) {
+	ring.mutex.RLock()
+	defer ring.mutex.RUnlock()
+	key, ok := ring.keys[id]
+	return key, ok
+}
+
+func hmacSum(key, record []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(record)
+	return mac.Sum(nil)
+}
+
+//the signature is added as the last field of the record:
+//	{...,"hmac":"<key id>:<hex sum>"}	in JSON records, as a JSON string
+//	... hmac=<key id>:<hex sum>		in other records
+const (
+	jsonHMACKey    = `"hmac":`
+	textHMACPrefix = ` hmac=`
+)
+
+//SignRecords wraps an encoder to add an HMAC-SHA256 of each encoded record
+//signed with the current key in the ring, so that forwarded logs can be
+//authenticated by the collector with VerifyRecord()
+func SignRecords(e IEncoder, ring *HMACKeyRing) IEncoder {
+	return hmacSigner{encoder: e, ring: ring}
+}
+
+type hmacSigner struct {
+	encoder IEncoder
+	ring    *HMACKeyRing
+}
+
+func (s hmacSigner) Encode(l ILogger, r Record) []byte {
+	record, newline := trimNewline(s.encoder.Encode(l, r))
+	id, sum := s.ring.sign(record)
+	signature := id + ":" + hex.EncodeToString(sum)
+	signed := make([]byte, 0, len(record)+len(jsonHMACKey)+len(signature)+8)
+	if bytes.HasSuffix(record, []byte("}")) {
+		//the key id may need escaping
+		value, _ := json.Marshal(signature)
+		body := record[:len(record)-1]
+		signed = append(signed, body...)
+		if !bytes.HasSuffix(bytes.TrimSpace(body), []byte("{")) {
+			signed = append(signed, ',')
+		}
+		signed = append(signed, jsonHMACKey...)
+		signed = append(signed, value...)
+		signed = append(signed, '}')
+	} else {
+		signed = append(signed, record...)
+		signed = append(signed, textHMACPrefix+signature...)
+	}
+	return append(signed, newline...)
+} //hmacSigner.Encode()
+
+//VerifyRecord checks the signature added by SignRecords() and returns
+//the record as it was before signing, or an error if the signature is
+//missing, the key id is not in the ring or the record was modified
+func VerifyRecord(ring *HMACKeyRing, signed []byte) ([]byte, error) {
+	signed, newline := trimNewline(signed)
+	var record []byte
+	var signature string
+	if i := bytes.LastIndex(signed, []byte(jsonHMACKey+`"`)); i > 0 && bytes.HasSuffix(signed, []byte(`"}`)) {
+		if err := json.Unmarshal(signed[i+len(jsonHMACKey):len(signed)-1], &signature); err != nil {
+			return nil, fmt.Errorf("invalid signature: %v", err)
+		}
+		body := signed[:i]
+		if body[len(body)-1] == ',' {
+			body = body[:len(body)-1]
+		} else if !bytes.HasSuffix(bytes.TrimSpace(body), []byte("{")) {
+			return nil, fmt.Errorf("record not signed")
+		}
+		record = append(append([]byte{}, body...), '}')
+	} else if i := bytes.LastIndex(signed, []byte(textHMACPrefix)); i >= 0 {
+		record = append([]byte{}, signed[:i]...)
+		signature = string(signed[i+len(textHMACPrefix):])
+	} else {
+		return nil, fmt.Errorf("record not signed")
+	}
+	i := bytes.LastIndexByte([]byte(signature), ':')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid signature %q", signature)
+	}
+	key, ok := ring.key(signature[:i])
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", signature[:i])
+	}
+	sum, err := hex.DecodeString(signature[i+1:])
+	if err != nil || !hmac.Equal(sum, hmacSum(key, record)) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	return append(record, newline...), nil
+} //VerifyRecord()
+
+//trimNewline splits the trailing newline (if any) from an encoded record
+func trimNewline(record []byte) ([]byte, []byte) {
+	if bytes.HasSuffix(record, []byte("\r\n")) {
+		return record[:len(record)-2], record[len(record)-2:]
+	}
+	if bytes.HasSuffix(record, []byte("\n")) {
+		return record[:len(record)-1], record[len(record)-1:]
+	}
+	return record, nil
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+//rawEncoder writes a fixed record, to test wrappers of encoders
+type rawEncoder string
+
+func (e rawEncoder) Encode(l ILogger, r Record) []byte {
+	return []byte(e)
+}
+
+func TestSignRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		keyID  string
+		record string
+		json   bool
+	}{
+		{"json", "k1", "{\"message\":\"hello\"}\n", true},
+		{"empty json", "k1", "{}\n", true},
+		{"json with spaces", "k1", "{ }", true},
+		{"escaped key id", `k"1\`, "{\"a\":1}\n", true},
+		{"text", "k1", "2000-01-01 info hello\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := NewHMACKeyRing(tt.keyID, []byte("secret"))
+			signed := SignRecords(rawEncoder(tt.record), ring).Encode(Top(), Record{})
+			if tt.json && !json.Valid(signed) {
+				t.Fatalf("invalid JSON %s", signed)
+			}
+			record, err := VerifyRecord(ring, signed)
+			if err != nil {
+				t.Fatalf("VerifyRecord(%s) = %v", signed, err)
+			}
+			if string(record) != tt.record {
+				t.Fatalf("record = %q, want %q", record, tt.record)
+			}
+
+			tampered := bytes.Replace(signed, []byte("1"), []byte("2"), 1)
+			if _, err := VerifyRecord(ring, tampered); err == nil {
+				t.Fatalf("tampered record %s verified", tampered)
+			}
+			if _, err := VerifyRecord(NewHMACKeyRing(tt.keyID, []byte("other")), signed); err == nil {
+				t.Fatal("verified with the wrong key")
+			}
+		})
+	}
+}
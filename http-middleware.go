@@ -0,0 +1,81 @@
+package log
+
+import (
+	"context"
+	"net/http"
+)
+
+type loggerContextKey struct{}
+
+type traceContextKey struct{}
+
+//ContextWithLogger returns a copy of ctx that carries the logger
+func ContextWithLogger(ctx context.Context, l ILogger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+//FromContext returns the logger set with ContextWithLogger(), e.g. by
+//HTTPMiddleware(), or def if there is none
+func FromContext(ctx context.Context, def ILogger) ILogger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerContextKey{}).(ILogger); ok {
+			return l
+		}
+	}
+	return def
+}
+
+//ContextWithTraceContext returns a copy of ctx that carries the received
+//trace context, for NewRoundTripper() to propagate it
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+//TraceContextFromContext returns the trace context set with
+//ContextWithTraceContext(), else the span of the context (see
+//SpanFromContext()) as sampled
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+	if tc, ok := ctx.Value(traceContextKey{}).(TraceContext); ok {
+		return tc, true
+	}
+	if sc, ok := SpanFromContext(ctx); ok {
+		return TraceContext{SpanContext: sc, Flags: 0x01}, true
+	}
+	return TraceContext{}, false
+} //TraceContextFromContext()
+
+//HTTPMiddleware wraps a handler to pass a request logger in the request
+//context (see FromContext()) with the trace context of the request
+//attached (see WithTraceContext()). When a trace context was received, a
+//new server span is started as its child without needing a tracing SDK:
+//it is stored in the request context (see TraceContextFromContext()) and
+//as its span (see SpanFromContext()) when there is no span yet, so that
+//it is propagated by NewRoundTripper(), and its id is added to the request
+//logger as "span_id".
+//	http.ListenAndServe(addr, log.HTTPMiddleware(log.Logger("http"))(mux))
+func HTTPMiddleware(l ILogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			rl := WithTraceContext(l, r)
+			if tc, ok := ExtractTraceContext(r.Header); ok {
+				server := tc.Child()
+				if sc, ok := SpanFromContext(ctx); ok {
+					//a tracing SDK already started the server span
+					server.SpanID = sc.SpanID
+				} else {
+					ctx = ContextWithSpan(ctx, server.SpanContext)
+				}
+				ctx = ContextWithTraceContext(ctx, server)
+				if sub, ok := rl.(*logger); ok && sub != l {
+					sub.data["span_id"] = server.SpanIDHex()
+				}
+			}
+			ctx = ContextWithLogger(ctx, rl)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+} //HTTPMiddleware()
@@ -0,0 +1,280 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//HTTPConfig configures an HTTP writer
+type HTTPConfig struct {
+	//URL to POST batches of records to
+	URL string
+	//ContentType of the batches, default "application/x-ndjson"
+	ContentType string
+	//BatchSize is the nr of bytes that triggers sending a batch, default 1MB
+	BatchSize int
+	//Interval at which a partial batch is sent, default 1s
+	Interval time.Duration
+	//Transport configures TLS and auth, the TLS part is not used with Client
+	Transport TransportOptions
+	//Client sends the requests when set, e.g. to use a custom proxy or
+	//round tripper. By default a client is made that uses HTTP(S)_PROXY
+	//from the environment and Dialer when set.
+	Client *http.Client
+	//Dialer makes the connections of the default client, e.g. through
+	//SOCKS or to a unix socket (see UnixSocketDialer)
+	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+	//Timeout of each request, default 30s
+	Timeout time.Duration
+	//QueueSize is the nr of full batches waiting to be sent, after which
+	//Write returns an error so that records can be spooled instead (see
+	//NewSpoolWriter), default 8
+	QueueSize int
+	//Compression of batches, "gzip" or "" for none. When the collector
+	//rejects compressed batches with 415 Unsupported Media Type, the batch
+	//is sent again uncompressed and later batches are not compressed.
+	Compression string
+}
+
+//UnixSocketDialer returns an HTTPConfig.Dialer that connects to the
+//unix socket at path for any address, e.g. for a local agent
+func UnixSocketDialer(path string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	}
+}
+
+//NewHTTPWriter returns a writer that collects records in batches and POSTs
+//them to the URL when the batch is full, every interval, and on Flush() and
+//Close(). Batches are sent in the background so that a slow collector does
+//not block the loggers. A batch that failed to send is reported to the
+//error handler and kept to be sent again at the next interval, before
+//later batches, which are queued meanwhile. Write fails when the queue is
+//full, Flush() returns the error of sending all batches.
+func NewHTTPWriter(c HTTPConfig) (IBufferedWriter, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("missing HTTP writer URL")
+	}
+	if c.ContentType == "" {
+		c.ContentType = "application/x-ndjson"
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 1 << 20
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 8
+	}
+	if c.Compression != "" && c.Compression != "gzip" {
+		return nil, fmt.Errorf("unsupported HTTP writer compression %q", c.Compression)
+	}
+	if c.Client == nil {
+		tc, err := c.Transport.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		t := &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     tc,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        2,
+			IdleConnTimeout:     90 * time.Second,
+		}
+		if c.Dialer != nil {
+			t.DialContext = c.Dialer
+		} else {
+			t.DialContext = (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+		}
+		c.Client = &http.Client{Transport: t}
+	}
+	hw := &httpWriter{
+		config:  c,
+		queue:   make(chan []byte, c.QueueSize),
+		full:    make(chan struct{}, 1),
+		flushes: make(chan chan error),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go hw.sendEvery(c.Interval)
+	return hw, nil
+} //NewHTTPWriter()
+
+//httpWriter implements IBufferedWriter
+//the mutex protects batch, the other fields are only used by sendEvery()
+type httpWriter struct {
+	mutex     sync.Mutex
+	config    HTTPConfig
+	batch     []byte
+	queue     chan []byte   //full batches
+	full      chan struct{} //signals a batch added to queue
+	flushes   chan chan error
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+	failed    []byte //batch to send again before the queued ones
+}
+
+func (hw *httpWriter) Write(p []byte) (int, error) {
+	hw.mutex.Lock()
+	defer hw.mutex.Unlock()
+	hw.batch = append(hw.batch, p...)
+	if len(hw.batch) < hw.config.BatchSize {
+		return len(p), nil
+	}
+	select {
+	case hw.queue <- hw.batch:
+		hw.batch = nil
+		select {
+		case hw.full <- struct{}{}:
+		default:
+		}
+		return len(p), nil
+	default:
+		//not accepted, so that the caller can keep it
+		hw.batch = hw.batch[:len(hw.batch)-len(p)]
+		return 0, fmt.Errorf("cannot send to %s: queue of %d batches is full", hw.config.URL, cap(hw.queue))
+	}
+} //httpWriter.Write()
+
+//Flush sends all batches and returns the first error
+func (hw *httpWriter) Flush() error {
+	reply := make(chan error, 1)
+	select {
+	case hw.flushes <- reply:
+		return <-reply
+	case <-hw.done:
+		return fmt.Errorf("HTTP writer is closed")
+	}
+}
+
+//Close sends all batches and stops sending
+func (hw *httpWriter) Close() error {
+	hw.closeOnce.Do(func() {
+		close(hw.stop)
+		<-hw.done
+	})
+	return hw.closeErr
+}
+
+//sendEvery sends the batches in the background, every interval, when
+//a batch is full, on Flush() and when closed
+func (hw *httpWriter) sendEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-hw.stop:
+			hw.closeErr = hw.sendAll()
+			close(hw.done)
+			return
+		case reply := <-hw.flushes:
+			reply <- hw.sendAll()
+		case <-hw.full:
+			//after a failure, wait for the next interval to send again
+			if hw.failed == nil {
+				if err := hw.sendAll(); err != nil {
+					handleError(err)
+				}
+			}
+		case <-ticker.C:
+			if err := hw.sendAll(); err != nil {
+				handleError(err)
+			}
+		}
+	}
+} //httpWriter.sendEvery()
+
+//sendAll sends the failed batch, the queued batches and the current batch
+//in order, and keeps the first one that fails
+func (hw *httpWriter) sendAll() error {
+	if hw.failed != nil {
+		statsOf(hw).retry()
+		if err := hw.send(hw.failed); err != nil {
+			return err
+		}
+		hw.failed = nil
+	}
+	for {
+		var batch []byte
+		select {
+		case batch = <-hw.queue:
+		default:
+			hw.mutex.Lock()
+			batch = hw.batch
+			hw.batch = nil
+			hw.mutex.Unlock()
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := hw.send(batch); err != nil {
+			hw.failed = batch
+			return err
+		}
+	}
+} //httpWriter.sendAll()
+
+//send posts a batch, uncompressed when the collector does not accept gzip
+func (hw *httpWriter) send(batch []byte) error {
+	if hw.config.Compression == "gzip" {
+		status, err := hw.post(batch, "gzip")
+		if status != http.StatusUnsupportedMediaType {
+			return err
+		}
+		//collector does not accept gzip, stop compressing
+		hw.config.Compression = ""
+		statsOf(hw).retry()
+	}
+	_, err := hw.post(batch, "")
+	return err
+} //httpWriter.send()
+
+//post sends the batch with the content encoding and returns the HTTP status
+func (hw *httpWriter) post(batch []byte, encoding string) (int, error) {
+	body := batch
+	if encoding == "gzip" {
+		buf := bytes.NewBuffer(make([]byte, 0, len(batch)/4))
+		zw := gzip.NewWriter(buf)
+		zw.Write(batch)
+		zw.Close()
+		body = buf.Bytes()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hw.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodPost, hw.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("cannot create request to %s: %v", hw.config.URL, err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", hw.config.ContentType)
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	hw.config.Transport.SetHeaders(req)
+	res, err := hw.config.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("cannot send %d bytes to %s: %v", len(batch), hw.config.URL, err)
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("cannot send %d bytes to %s: %s", len(batch), hw.config.URL, res.Status)
+	}
+	statsOf(hw).batch(len(body))
+	return res.StatusCode, nil
+} //httpWriter.post()
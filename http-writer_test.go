@@ -0,0 +1,166 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//collector is a test server that fails while down and blocks while held
+type collector struct {
+	mutex    sync.Mutex
+	down     bool
+	hold     chan struct{}
+	received []string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mutex.Lock()
+	hold := c.hold
+	c.mutex.Unlock()
+	if hold != nil {
+		<-hold
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	c.received = append(c.received, string(body))
+}
+
+func (c *collector) set(down bool, hold chan struct{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.down = down
+	c.hold = hold
+}
+
+func (c *collector) all() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return strings.Join(c.received, "")
+}
+
+func TestHTTPWriterDoesNotBlockOnSlowCollector(t *testing.T) {
+	hold := make(chan struct{})
+	c := &collector{hold: hold}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	w, err := NewHTTPWriter(HTTPConfig{URL: srv.URL, BatchSize: 1, QueueSize: 2, Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//one batch is being sent, two are queued, then the queue is full
+	done := make(chan error)
+	written := 0
+	go func() {
+		for i := 0; i < 10; i++ {
+			if _, err := w.Write([]byte("r\n")); err != nil {
+				done <- err
+				return
+			}
+			written++
+			time.Sleep(10 * time.Millisecond)
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "queue") {
+			t.Fatalf("Write() = %v, want queue full", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write blocked on a slow collector")
+	}
+	close(hold)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.all(); got != strings.Repeat("r\n", written) {
+		t.Fatalf("received %q after %d writes", got, written)
+	}
+}
+
+func TestHTTPWriterKeepsFailedBatch(t *testing.T) {
+	c := &collector{down: true}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	w, err := NewHTTPWriter(HTTPConfig{URL: srv.URL, Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Write([]byte("one\n"))
+	if err := w.Flush(); err == nil {
+		t.Fatal("Flush() succeeded while the collector is down")
+	}
+	w.Write([]byte("two\n"))
+	c.set(false, nil)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.all(); got != "one\ntwo\n" {
+		t.Fatalf("received %q", got)
+	}
+}
+
+func TestHTTPWriterCompression(t *testing.T) {
+	tests := []struct {
+		name      string
+		gzip      bool     //collector accepts gzip
+		encodings []string //Content-Encoding of each request
+	}{
+		{"gzip accepted", true, []string{"gzip", "gzip"}},
+		{"gzip rejected", false, []string{"gzip", "", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var encodings []string
+			received := ""
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding := r.Header.Get("Content-Encoding")
+				encodings = append(encodings, encoding)
+				if encoding == "gzip" && !tt.gzip {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				body := io.Reader(r.Body)
+				if encoding == "gzip" {
+					zr, err := gzip.NewReader(r.Body)
+					if err != nil {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					body = zr
+				}
+				b, _ := ioutil.ReadAll(body)
+				received += string(b)
+			}))
+			defer srv.Close()
+			w, err := NewHTTPWriter(HTTPConfig{URL: srv.URL, Interval: time.Hour, Compression: "gzip"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			for _, line := range []string{"one\n", "two\n"} {
+				w.Write([]byte(line))
+				if err := w.Flush(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if received != "one\ntwo\n" || strings.Join(encodings, ",") != strings.Join(tt.encodings, ",") {
+				t.Fatalf("received %q with encodings %q, want %q", received, encodings, tt.encodings)
+			}
+		})
+	}
+}
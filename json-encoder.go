@@ -0,0 +1,554 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+//NewJSONEncoder returns an encoder that writes each record as one line of JSON
+//with the standard keys (time, level, logger, caller, message) followed by
+//static values and then all data values. Grouped (dotted) data names are written as nested objects, e.g.
+//"http.method" and "http.status" are written as "http":{"method":...,"status":...}
+//Struct, map and slice values are written as nested JSON up to a max depth.
+func NewJSONEncoder() IJSONEncoder {
+	return jsonEncoder{
+		maxDepth:   10,
+		timeFormat: time.RFC3339Nano,
+	}
+}
+
+//Time formats for IJSONEncoder.WithTimeFormat() in addition to any time layout
+const (
+	//TimeEpochSeconds writes the time as a number of seconds since 1970 with fraction
+	TimeEpochSeconds = "epoch-s"
+	//TimeEpochMillis writes the time as an integer number of milliseconds since 1970
+	TimeEpochMillis = "epoch-ms"
+	//TimeEpochNanos writes the time as an integer number of nanoseconds since 1970
+	TimeEpochNanos = "epoch-ns"
+)
+
+//IJSONEncoder is an encoder for JSON output
+type IJSONEncoder interface {
+	IEncoder
+	//WithMaxDepth limits nesting of data values, deeper values are written as "<max depth>"
+	WithMaxDepth(depth int) IJSONEncoder
+	//WithKey renames a standard key, e.g. WithKey("message", "msg"),
+	//or omits it when name is "". The standard keys are "time", "level",
+	//"level_label" (only written for levels with a custom name, see
+	//SetLevelName()), "logger", "caller", "message" and "event" (written
+	//instead of "message" for records from ILogger.Event()).
+	//It panics for other standard key names.
+	WithKey(standard, name string) IJSONEncoder
+	//WithStatic adds a name-value to every record, e.g. WithStatic("service", "billing")
+	//a dotted name is written as nested objects like grouped data
+	WithStatic(name string, value interface{}) IJSONEncoder
+	//WithTimeFormat sets a time layout, e.g. time.RFC3339, or one of
+	//the epoch formats, e.g. TimeEpochMillis (default is time.RFC3339Nano)
+	WithTimeFormat(format string) IJSONEncoder
+	//WithCallerFormat sets the format of the caller (default is "<full path>:<line>")
+	WithCallerFormat(format CallerFormat) IJSONEncoder
+	//WithEscapeHTML escapes <, > and & in strings as \u003c etc. so that records
+	//shown in web-based log viewers cannot inject script (default is false)
+	WithEscapeHTML(escape bool) IJSONEncoder
+	//WithInvalidUTF8 sets what is done with strings that are not valid UTF-8
+	//(default is ReplaceInvalidUTF8)
+	WithInvalidUTF8(handling InvalidUTF8) IJSONEncoder
+}
+
+//InvalidUTF8 is how the JSON encoder handles strings that are not valid UTF-8
+type InvalidUTF8 int
+
+const (
+	//ReplaceInvalidUTF8 replaces invalid bytes with the replacement character U+FFFD
+	ReplaceInvalidUTF8 InvalidUTF8 = iota
+	//RejectInvalidUTF8 fails to encode the record, reporting it to the error handler
+	RejectInvalidUTF8
+)
+
+//standard keys written in each JSON record, in this order
+var jsonStandardKeys = []string{"time", "level", "logger", "caller", "message", "event"}
+
+//isJSONStandardKey is true for the keys that can be renamed with WithKey()
+func isJSONStandardKey(standard string) bool {
+	if standard == "level_label" {
+		return true
+	}
+	for _, std := range jsonStandardKeys {
+		if std == standard {
+			return true
+		}
+	}
+	return false
+}
+
+//jsonEncoder implements IJSONEncoder
+type jsonEncoder struct {
+	maxDepth     int
+	keys         map[string]string
+	static       []jsonField
+	timeFormat   string
+	callerFormat CallerFormat
+	escapeHTML   bool
+	invalidUTF8  InvalidUTF8
+}
+
+type jsonField struct {
+	name  string
+	value interface{}
+}
+
+func (je jsonEncoder) WithKey(standard, name string) IJSONEncoder {
+	if !isJSONStandardKey(standard) {
+		panic(fmt.Sprintf("unknown standard key %q", standard))
+	}
+	keys := map[string]string{}
+	for s, n := range je.keys {
+		keys[s] = n
+	}
+	keys[standard] = name
+	je.keys = keys
+	return je
+}
+
+func (je jsonEncoder) WithStatic(name string, value interface{}) IJSONEncoder {
+	static := make([]jsonField, 0, len(je.static)+1)
+	for _, f := range je.static {
+		if f.name != name {
+			static = append(static, f)
+		}
+	}
+	je.static = append(static, jsonField{name: name, value: value})
+	return je
+}
+
+func (je jsonEncoder) WithTimeFormat(format string) IJSONEncoder {
+	if format != "" {
+		je.timeFormat = format
+	}
+	return je
+}
+
+func (je jsonEncoder) WithCallerFormat(format CallerFormat) IJSONEncoder {
+	je.callerFormat = format
+	return je
+}
+
+func (je jsonEncoder) WithEscapeHTML(escape bool) IJSONEncoder {
+	je.escapeHTML = escape
+	return je
+}
+
+func (je jsonEncoder) WithInvalidUTF8(handling InvalidUTF8) IJSONEncoder {
+	je.invalidUTF8 = handling
+	return je
+}
+
+//timeValue returns the record time as a string or number for the time format
+func (je jsonEncoder) timeValue(t time.Time) interface{} {
+	switch je.timeFormat {
+	case TimeEpochSeconds:
+		return float64(t.UnixNano()) / float64(time.Second)
+	case TimeEpochMillis:
+		return t.UnixNano() / int64(time.Millisecond)
+	case TimeEpochNanos:
+		return t.UnixNano()
+	default:
+		return t.Format(je.timeFormat)
+	}
+}
+
+//key returns the name to write for the standard key
+func (je jsonEncoder) key(standard string) string {
+	if n, ok := je.keys[standard]; ok {
+		return n
+	}
+	return standard
+}
+
+func (je jsonEncoder) WithMaxDepth(depth int) IJSONEncoder {
+	if depth > 0 {
+		je.maxDepth = depth
+	}
+	return je
+}
+
+//Encode ...
+func (je jsonEncoder) Encode(l ILogger, r Record) []byte {
+	obj := newJSONObject()
+	for _, std := range jsonStandardKeys {
+		key := je.key(std)
+		if key == "" {
+			continue
+		}
+		switch std {
+		case "time":
+			obj.set(key, je.timeValue(r.Time))
+		case "level":
+			obj.set(key, r.Level.String())
+			if name, ok := r.Level.customName(); ok {
+				if labelKey := je.key("level_label"); labelKey != "" {
+					obj.set(labelKey, name)
+				}
+			}
+		case "logger":
+			obj.set(key, l.Name())
+		case "caller":
+			obj.set(key, je.callerFormat.Format(r.Caller))
+		case "message":
+			if r.Event == "" {
+				obj.set(key, r.Message)
+			}
+		case "event":
+			if r.Event != "" {
+				obj.set(key, r.Event)
+			}
+		}
+	}
+	for _, f := range je.static {
+		obj.setPath(f.name, f.value)
+	}
+
+	data := recordData(l, r)
+	names := make([]string, 0, len(data))
+	for n := range data {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if _, isStandard := obj.values[n]; isStandard {
+			//do not overwrite the standard keys or static values
+			obj.setPath("data."+n, data[n])
+			continue
+		}
+		obj.setPath(n, data[n])
+	}
+
+	buf := bytes.NewBuffer(nil)
+	obj.encode(jsonValueWriter{
+		buf:         buf,
+		maxDepth:    je.maxDepth,
+		escapeHTML:  je.escapeHTML,
+		invalidUTF8: je.invalidUTF8,
+	})
+	buf.WriteByte('\n')
+	return buf.Bytes()
+} //jsonEncoder.Encode()
+
+//jsonObject is a JSON object that retains the order in which keys are added
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{
+		keys:   []string{},
+		values: map[string]interface{}{},
+	}
+}
+
+func (o *jsonObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+//setPath sets a dotted name as nested objects, but if a name in the path
+//already has a non-object value, the rest of the path is used as a flat key
+func (o *jsonObject) setPath(path string, value interface{}) {
+	names := strings.SplitN(path, ".", 2)
+	if len(names) == 1 {
+		o.set(path, value)
+		return
+	}
+	existing, ok := o.values[names[0]]
+	if !ok {
+		sub := newJSONObject()
+		o.set(names[0], sub)
+		sub.setPath(names[1], value)
+		return
+	}
+	if sub, isObject := existing.(*jsonObject); isObject {
+		sub.setPath(names[1], value)
+		return
+	}
+	o.set(path, value)
+} //jsonObject.setPath()
+
+func (o *jsonObject) encode(jw jsonValueWriter) {
+	jw.buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			jw.buf.WriteByte(',')
+		}
+		jw.writeString(key)
+		jw.buf.WriteByte(':')
+		if sub, isObject := o.values[key].(*jsonObject); isObject {
+			sub.encode(jw)
+		} else {
+			jw.visiting = map[uintptr]bool{}
+			jw.write(reflect.ValueOf(o.values[key]), 0)
+		}
+	}
+	jw.buf.WriteByte('}')
+} //jsonObject.encode()
+
+//writeString writes s as a quoted JSON string, escaping HTML characters
+//only when configured, because log records are mostly not shown in HTML
+func (jw jsonValueWriter) writeString(s string) {
+	if jw.invalidUTF8 == RejectInvalidUTF8 && !utf8.ValidString(s) {
+		//encode() reports this to the error handler
+		panic(fmt.Errorf("invalid UTF-8 in %q", s))
+	}
+	enc := json.NewEncoder(jw.buf)
+	enc.SetEscapeHTML(jw.escapeHTML)
+	enc.Encode(s)
+	jw.buf.Truncate(jw.buf.Len() - 1) //remove newline written by Encode()
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	durationType      = reflect.TypeOf(time.Duration(0))
+)
+
+//jsonValueWriter writes any value as JSON, with structs, maps and slices
+//written as nested JSON up to maxDepth, and cyclic references written
+//as "<cycle>" rather than recursing forever
+type jsonValueWriter struct {
+	buf         *bytes.Buffer
+	maxDepth    int
+	visiting    map[uintptr]bool
+	escapeHTML  bool
+	invalidUTF8 InvalidUTF8
+}
+
+func (jw jsonValueWriter) write(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		jw.buf.WriteString("null")
+		return
+	}
+
+	//types that know how to write themselves
+	switch {
+	case v.Type().Implements(jsonMarshalerType):
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			jw.buf.WriteString("null")
+			return
+		}
+		jsonValue, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err == nil && !json.Valid(jsonValue) {
+			err = fmt.Errorf("invalid JSON")
+		}
+		if err != nil {
+			handleError(fmt.Errorf("JSON encoding of %T failed: %v", v.Interface(), err))
+			jw.writeString(fmt.Sprintf("%v", v.Interface()))
+			return
+		}
+		if jw.escapeHTML {
+			json.HTMLEscape(jw.buf, jsonValue)
+		} else {
+			jw.buf.Write(jsonValue)
+		}
+		return
+	case v.Type().Implements(errorType):
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			jw.buf.WriteString("null")
+			return
+		}
+		jw.writeString(v.Interface().(error).Error())
+		return
+	case v.Type() == durationType:
+		jw.writeString(v.Interface().(time.Duration).String())
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		jw.writeString(v.String())
+
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		jsonValue, err := json.Marshal(v.Interface())
+		if err != nil {
+			//e.g. NaN or Inf
+			jw.writeString(fmt.Sprintf("%v", v.Interface()))
+			return
+		}
+		jw.buf.Write(jsonValue)
+
+	case reflect.Interface:
+		jw.write(v.Elem(), depth)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			jw.buf.WriteString("null")
+			return
+		}
+		if jw.visiting[v.Pointer()] {
+			jw.writeString("<cycle>")
+			return
+		}
+		jw.visiting[v.Pointer()] = true
+		jw.write(v.Elem(), depth)
+		delete(jw.visiting, v.Pointer())
+
+	case reflect.Struct:
+		if depth >= jw.maxDepth {
+			jw.writeString("<max depth>")
+			return
+		}
+		jw.buf.WriteByte('{')
+		jw.writeFields(v, depth, true)
+		jw.buf.WriteByte('}')
+
+	case reflect.Map:
+		if v.IsNil() {
+			jw.buf.WriteString("null")
+			return
+		}
+		if depth >= jw.maxDepth {
+			jw.writeString("<max depth>")
+			return
+		}
+		if jw.visiting[v.Pointer()] {
+			jw.writeString("<cycle>")
+			return
+		}
+		jw.visiting[v.Pointer()] = true
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = fmt.Sprintf("%v", k.Interface())
+		}
+		sort.Sort(mapKeys{keys: keys, names: names})
+		jw.buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				jw.buf.WriteByte(',')
+			}
+			jw.writeString(names[i])
+			jw.buf.WriteByte(':')
+			jw.write(v.MapIndex(k), depth+1)
+		}
+		jw.buf.WriteByte('}')
+		delete(jw.visiting, v.Pointer())
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice {
+			if v.IsNil() {
+				jw.buf.WriteString("null")
+				return
+			}
+			if v.Type().Elem().Kind() == reflect.Uint8 {
+				//[]byte is written as base64 like encoding/json does
+				jsonValue, _ := json.Marshal(v.Bytes())
+				jw.buf.Write(jsonValue)
+				return
+			}
+		}
+		if depth >= jw.maxDepth {
+			jw.writeString("<max depth>")
+			return
+		}
+		jw.buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				jw.buf.WriteByte(',')
+			}
+			jw.write(v.Index(i), depth+1)
+		}
+		jw.buf.WriteByte(']')
+
+	default:
+		//chan, func, complex, unsafe pointer
+		jw.writeString(fmt.Sprintf("%v", v.Interface()))
+	}
+} //jsonValueWriter.write()
+
+//writeFields writes the exported fields of a struct (without the surrounding braces)
+//using the same json tags as encoding/json, with embedded structs flattened.
+//It returns false if nothing was written.
+func (jw jsonValueWriter) writeFields(v reflect.Value, depth int, first bool) bool {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		omitEmpty := false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+		fv := v.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" && fv.Kind() == reflect.Struct {
+			if jw.writeFields(fv, depth, first) {
+				first = false
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue //not exported
+		}
+		if omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if !first {
+			jw.buf.WriteByte(',')
+		}
+		first = false
+		jw.writeString(name)
+		jw.buf.WriteByte(':')
+		jw.write(fv, depth+1)
+	}
+	return !first
+} //jsonValueWriter.writeFields()
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+//mapKeys sorts map keys on their text
+type mapKeys struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (m mapKeys) Len() int           { return len(m.keys) }
+func (m mapKeys) Less(i, j int) bool { return m.names[i] < m.names[j] }
+func (m mapKeys) Swap(i, j int) {
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+	m.names[i], m.names[j] = m.names[j], m.names[i]
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONEncoderStrings(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoder IJSONEncoder
+		message string
+		raw     string //expected encoding of the message
+		decoded string //expected message after decoding
+	}{
+		{"plain", NewJSONEncoder(), "hello", `"hello"`, "hello"},
+		{"quotes", NewJSONEncoder(), `say "hi" \ bye`, `"say \"hi\" \\ bye"`, `say "hi" \ bye`},
+		{"control", NewJSONEncoder(), "a\tb\x01c", `"a\tb\u0001c"`, "a\tb\x01c"},
+		{"html as is", NewJSONEncoder(), "<a>&", `"<a>&"`, "<a>&"},
+		{"html escaped", NewJSONEncoder().WithEscapeHTML(true), "<a>&", `"\u003ca\u003e\u0026"`, "<a>&"},
+		{"multibyte", NewJSONEncoder(), "héllo 世界 \U0001f600", "\"héllo 世界 \U0001f600\"", "héllo 世界 \U0001f600"},
+		{"line separator", NewJSONEncoder(), "a\u2028b", `"a\u2028b"`, "a\u2028b"},
+		{"invalid replaced", NewJSONEncoder(), "a\xffb", "\"a�b\"", "a�b"},
+		{"truncated rune replaced per byte", NewJSONEncoder().WithInvalidUTF8(ReplaceInvalidUTF8), "a\xe4\xb8", "\"a��\"", "a��"},
+	}
+	l := Top().Temp("jsontest")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := tt.encoder.WithKey("time", "").WithKey("caller", "").Encode(l, Record{Level: InfoLevel, Message: tt.message})
+			if !strings.Contains(string(out), `"message":`+tt.raw) {
+				t.Fatalf("encoded %s, want message %s", out, tt.raw)
+			}
+			var obj map[string]interface{}
+			if err := json.Unmarshal(out, &obj); err != nil {
+				t.Fatalf("invalid JSON %s: %v", out, err)
+			}
+			if obj["message"] != tt.decoded {
+				t.Fatalf("decoded %q, want %q", obj["message"], tt.decoded)
+			}
+		})
+	}
+}
+
+func TestJSONEncoderRejectInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  Record
+		wantErr bool
+	}{
+		{"valid", Record{Message: "héllo"}, false},
+		{"invalid message", Record{Message: "a\xffb"}, true},
+		{"invalid field", Record{Message: "ok", Fields: []Field{{Name: "f", Value: "a\xffb"}}}, true},
+		{"invalid field name", Record{Message: "ok", Fields: []Field{{Name: "f\xff", Value: 1}}}, true},
+	}
+	e := NewJSONEncoder().WithInvalidUTF8(RejectInvalidUTF8)
+	l := Top().Temp("jsontest")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := encode(e, l, tt.record)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v for %s", err, out)
+			}
+			if !tt.wantErr && !json.Valid(out) {
+				t.Fatalf("invalid JSON %s", out)
+			}
+		})
+	}
+}
+
+func TestJSONEncoderWithKey(t *testing.T) {
+	tests := []struct {
+		standard  string
+		wantPanic bool
+	}{
+		{"time", false},
+		{"level", false},
+		{"level_label", false},
+		{"logger", false},
+		{"caller", false},
+		{"message", false},
+		{"event", false},
+		{"msg", true},
+		{"Message", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.standard, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Fatalf("panic = %v", r)
+				}
+			}()
+			NewJSONEncoder().WithKey(tt.standard, "renamed")
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package log
+
+import (
+	"sync"
+)
+
+var (
+	levelNamesMutex sync.RWMutex
+	levelNames      = map[Level]string{}
+)
+
+//SetLevelName overrides the name written for the level by all encoders,
+//e.g. SetLevelName(WarnLevel, "WARNING") or a localized name, or restores
+//the default name when name is "". The JSON encoder still writes the
+//canonical name (see Level.String()) and adds the custom name as "level_label".
+func SetLevelName(level Level, name string) {
+	levelNamesMutex.Lock()
+	defer levelNamesMutex.Unlock()
+	if name == "" {
+		delete(levelNames, level)
+		return
+	}
+	levelNames[level] = name
+}
+
+//SetLevelNames replaces all custom level names, nil restores the defaults
+func SetLevelNames(names map[Level]string) {
+	levelNamesMutex.Lock()
+	defer levelNamesMutex.Unlock()
+	levelNames = map[Level]string{}
+	for level, name := range names {
+		if name != "" {
+			levelNames[level] = name
+		}
+	}
+}
+
+//Label is the name written for the level, which is the name set with
+//SetLevelName() or else the canonical String()
+func (l Level) Label() string {
+	if name, ok := l.customName(); ok {
+		return name
+	}
+	return l.String()
+}
+
+func (l Level) customName() (string, bool) {
+	levelNamesMutex.RLock()
+	defer levelNamesMutex.RUnlock()
+	name, ok := levelNames[l]
+	return name, ok
+}
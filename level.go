@@ -25,7 +25,7 @@ const (
 
 	//PanicLevel logs then panic (temrminate the program)
 	PanicLevel
-	//FatalLevel logs a message, then calls os.Exit(1).
+	//FatalLevel logs a message, then applies the fatal behavior, os.Exit(1) by default (see SetFatalBehavior())
 	FatalLevel
 
 	// //internal levels:
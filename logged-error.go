@@ -0,0 +1,68 @@
+package log
+
+import (
+	"fmt"
+)
+
+//LoggedError is returned by ILogger.Errorw() and ILogger.NewError()
+//after the error was logged, so the caller need not log it again
+//Data is the logger data when the error was logged, as context
+//for the handler of the error
+type LoggedError struct {
+	Msg  string
+	Err  error
+	Data map[string]interface{}
+}
+
+//Error is "<msg>: <err>" or just "<msg>" when there is no wrapped error
+func (e *LoggedError) Error() string {
+	if e.Err == nil {
+		return e.Msg
+	}
+	if e.Msg == "" {
+		return e.Err.Error()
+	}
+	return e.Msg + ": " + e.Err.Error()
+}
+
+//Unwrap returns the wrapped error
+func (e *LoggedError) Unwrap() error {
+	return e.Err
+}
+
+func (l *logger) Errorw(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	l.log(0, ErrorLevel, msg, Field{Name: "error", Value: err})
+	return &LoggedError{
+		Msg:  msg,
+		Err:  err,
+		Data: l.Data(),
+	}
+} //logger.Errorw()
+
+func (l *logger) NewError(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	l.log(0, ErrorLevel, msg)
+	return &LoggedError{
+		Msg:  msg,
+		Data: l.Data(),
+	}
+} //logger.NewError()
+
+func (l *logger) Check(err error, msg string) bool {
+	if err == nil {
+		return false
+	}
+	l.log(0, ErrorLevel, msg, Field{Name: "error", Value: err})
+	return true
+} //logger.Check()
+
+func (l *logger) Must(err error) {
+	if err == nil {
+		return
+	}
+	l.log(0, FatalLevel, err.Error(), Field{Name: "error", Value: err})
+	l.fatal("", err)
+} //logger.Must()
@@ -68,6 +68,19 @@ type ILogger interface {
 	With(n string, v interface{}) ILogger
 	Get(n string) (interface{}, bool)
 
+	//Data returns a copy of all data values visible in this logger,
+	//i.e. its own and those inherited from parents
+	Data() map[string]interface{}
+
+	//WithGroup returns a temporary logger (see Temp()) that nests all
+	//data set through it under the group name, e.g. WithGroup("http").Set("method", m)
+	//sets "http.method". Get() and Data() always use the full dotted names.
+	WithGroup(g string) ILogger
+
+	//Ephemeral returns a pooled view with request-scoped data that does
+	//not change the logger tree, see IEphemeral
+	Ephemeral(fields ...Field) IEphemeral
+
 	//output functions
 	Log(level Level, msg string)
 	Trace(msg string)
@@ -77,6 +90,40 @@ type ILogger interface {
 	Error(msg string)
 	Fatal(msg string)
 
+	//LogDepth logs with fields added to this record only, reporting the
+	//caller depth frames above the caller of LogDepth, so that wrappers
+	//and adapters can report their own caller
+	LogDepth(depth int, level Level, msg string, fields ...Field)
+
+	//Errorw logs err with the message at ErrorLevel and returns a
+	//*LoggedError that wraps err with the message and logger data
+	//it returns nil if err is nil
+	Errorw(err error, msg string) error
+	//NewError logs the message at ErrorLevel and returns it as a
+	//*LoggedError with the logger data, e.g.
+	//	return l.NewError("cannot open %s", name)
+	NewError(format string, args ...interface{}) error
+
+	//Check logs a non-nil err at ErrorLevel with the message and
+	//returns true, or false if err is nil, e.g.
+	//	if l.Check(err, "cannot load config") { return }
+	Check(err error, msg string) bool
+	//FatalErr logs a non-nil err at FatalLevel then applies the fatal
+	//behavior (see SetFatalBehavior()), or does nothing if err is nil
+	FatalErr(err error)
+	//Must is the same as FatalErr(), e.g. l.Must(db.Ping())
+	Must(err error)
+
+	//builder output functions, which return nil when the level
+	//is not enabled, e.g. l.InfoEvent().Str("user", u).Msg("login")
+	LogEvent(level Level) *Builder
+	TraceEvent() *Builder
+	DebugEvent() *Builder
+	InfoEvent() *Builder
+	WarnEvent() *Builder
+	ErrorEvent() *Builder
+	FatalEvent() *Builder
+
 	//formatted output functions
 	Logf(level Level, format string, args ...interface{})
 	Tracef(format string, args ...interface{})
@@ -86,6 +133,26 @@ type ILogger interface {
 	Errorf(format string, args ...interface{})
 	Fatalf(format string, args ...interface{})
 
+	//template output functions, where named placeholders in the template are
+	//replaced by the args in order and also written as fields, with the
+	//template in field "message_template", e.g.
+	//	l.Infot("user {user} bought {count} items", u, n)
+	Logt(level Level, template string, args ...interface{})
+	Tracet(template string, args ...interface{})
+	Debugt(template string, args ...interface{})
+	Infot(template string, args ...interface{})
+	Warnt(template string, args ...interface{})
+	Errort(template string, args ...interface{})
+	Fatalt(template string, args ...interface{})
+
+	//Event writes a named event for analytics rather than a free text
+	//message, e.g. l.Event("order_placed", log.Field{Name: "amount", Value: 42})
+	//Events are written at InfoLevel even when the level of the logger is
+	//higher and are not sampled or demoted by SetFloodGuard(). The JSON
+	//encoder writes the name with key "event" and the console encoder as
+	//"event: <name>" in the message column.
+	Event(name string, fields ...Field)
+
 	//--------------------------------------------------------------------------
 	//NOTE: all "Set...()" and "With...()" methods updates the current logger and all children
 	// Loggers are not copied as they all exist in the tree
@@ -95,6 +162,7 @@ type ILogger interface {
 	//also update all children
 	SetLevel(l Level)
 	WithLevel(l Level) ILogger
+	Level() Level
 
 	//set the encode and return the same logger
 	//also update all children
@@ -105,6 +173,64 @@ type ILogger interface {
 	//also update all children
 	SetWriter(w io.Writer)
 	WithWriter(w io.Writer) ILogger
+	Writer() io.Writer
+
+	//set the schema that records must comply with, or nil for no schema
+	//also update all children
+	SetSchema(s *Schema)
+	WithSchema(s *Schema) ILogger
+
+	//set deep copy of data and field values (see DeepCopy()) when they are
+	//attached, so that the caller can change them while an async writer
+	//has not yet written them, also update all children
+	SetDeepCopy(on bool)
+	WithDeepCopy(on bool) ILogger
+
+	//set sampling to keep only 1 of every n records at the level,
+	//e.g. SetSampling(DebugLevel, 100), or all records when n <= 1
+	//also update all children
+	SetSampling(level Level, n int)
+	WithSampling(level Level, n int) ILogger
+
+	//set the glog-style verbosity used by V() and return the same logger
+	//also update all children
+	SetV(v int)
+	WithV(v int) ILogger
+
+	//V returns this logger if the verbosity is n or more, else a temp
+	//logger that discards all output, e.g. l.V(2).Infof(...)
+	V(n int) ILogger
+
+	//Once returns a temp logger that writes only the first record
+	//logged from each call site during the life of the process, e.g.
+	//	l.Once().Warn("deprecated option used")
+	Once() ILogger
+	//Every returns a temp logger that writes at most one record per
+	//interval from each call site, adding data "suppressed" with the
+	//nr of records that were not written since the previous one, e.g.
+	//	l.Every(30*time.Second).Info("reconnecting")
+	Every(interval time.Duration) ILogger
+	//First returns a temp logger that writes only the first n records
+	//from each call site, then one record to say that further messages
+	//are suppressed, e.g. l.First(10).Debugf("retry %d", i)
+	First(n int) ILogger
+
+	//IfError returns a temp logger with data "error" if err is not nil
+	//else a temp logger that discards all output, e.g.
+	//	l.IfError(err).Warnf("cannot close %s", name)
+	IfError(err error) ILogger
+	//IfTrue returns this logger if cond is true else a temp logger
+	//that discards all output
+	IfTrue(cond bool) ILogger
+
+	//DumpConfig writes a table of this logger and all its children
+	//showing the effective level, encoder, writer and data keys
+	DumpConfig(w io.Writer)
+	//SaveConfig writes the configuration of this logger and all its children
+	//to a JSON file that LoadConfig() accepts
+	SaveConfig(path string) error
+	//Config returns the configuration of this logger and all its children
+	Config() Config
 }
 
 //ValidName is a domain name identifier ""
@@ -127,13 +253,35 @@ type logger struct {
 	subs    map[string]ILogger
 	writer  io.Writer
 	encoder IEncoder
+	group   string
+	v       int
+	gate    func(r *Record) bool
+	counts  [_maxLevel - _minLevel + 1]uint64
+	rate    rateCounter
+	//sampling keeps 1 of every n records per level, 0 or 1 keeps all
+	sampling [_maxLevel - _minLevel + 1]int
+	sampled  [_maxLevel - _minLevel + 1]uint64
+	schema   *Schema
+	deepCopy bool
+	//pooled is set for Ephemeral() loggers, which are reused after Release()
+	pooled bool
+
+	secretsRedacted uint64
+
+	//last and lastError are updated in place so that counting a record
+	//does not allocate, a zero Time means there was no such record
+	lastMutex sync.Mutex
+	last      RecordSummary
+	lastError RecordSummary
 }
 
 func (l *logger) Logger(n string) ILogger {
 	sub := l.Temp(n)
 	//only difference between Temp() and Logger() is that parent keeps
 	//reference to the latter
+	l.mutex.Lock()
 	l.subs[n] = sub
+	l.mutex.Unlock()
 	return sub
 } //logger.Logger()
 
@@ -147,22 +295,64 @@ func (l *logger) Temp(n string) ILogger {
 		return exists
 	}
 	sub := &logger{
-		parent:  l,
-		name:    n,
-		level:   l.level,
-		data:    map[string]interface{}{},
-		subs:    map[string]ILogger{}, //inherits parent's data + own
-		writer:  l.writer,             //inherits parent's writer or replace with own
-		encoder: l.encoder,
+		parent:   l,
+		name:     n,
+		level:    l.level,
+		data:     map[string]interface{}{},
+		subs:     map[string]ILogger{}, //inherits parent's data + own
+		writer:   l.writer,             //inherits parent's writer or replace with own
+		encoder:  l.encoder,
+		group:    l.group,
+		v:        l.v,
+		sampling: l.sampling,
+		schema:   l.schema,
+		deepCopy: l.deepCopy,
 	}
 	return sub
 } //logger.Temp()
 
+func (l *logger) WithGroup(g string) ILogger {
+	if !ValidName(g) {
+		panic("invalid group name \"" + g + "\"")
+	}
+	return l.anon(l.key(g))
+} //logger.WithGroup()
+
+//anon returns an unnamed temp logger, which uses the name of its parent
+//but has its own data and settings
+func (l *logger) anon(group string) *logger {
+	return &logger{
+		parent:   l,
+		name:     "",
+		level:    l.level,
+		data:     map[string]interface{}{},
+		subs:     map[string]ILogger{},
+		writer:   l.writer,
+		encoder:  l.encoder,
+		group:    group,
+		v:        l.v,
+		sampling: l.sampling,
+		schema:   l.schema,
+		deepCopy: l.deepCopy,
+	}
+} //logger.anon()
+
+//key returns the data name n prefixed with the group (if any)
+func (l *logger) key(n string) string {
+	if l.group == "" {
+		return n
+	}
+	return l.group + "." + n
+}
+
 //Name of this logger
 func (l *logger) Name() string {
 	if l.parent == nil {
 		return l.name
 	}
+	if l.name == "" {
+		return l.parent.Name()
+	}
 	return l.parent.Name() + "/" + l.name
 } //logger.Name()
 
@@ -171,14 +361,18 @@ func (l *logger) Set(n string, v interface{}) {
 	if !ValidName(n) {
 		panic(fmt.Sprintf("logger.Set(%s) is invalid name", n))
 	}
+	v = l.copyValue(v)
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
-	l.data[n] = v
+	l.data[l.key(n)] = v
 } //logger.Set()
 
 //Get a data field from self else from parent else nil
 func (l *logger) Get(n string) (interface{}, bool) {
-	if v, ok := l.data[n]; ok {
+	l.mutex.Lock()
+	v, ok := l.data[n]
+	l.mutex.Unlock()
+	if ok {
 		return v, ok
 	}
 	if l.parent != nil {
@@ -187,43 +381,116 @@ func (l *logger) Get(n string) (interface{}, bool) {
 	return nil, false
 } //logger.Get()
 
-func (l *logger) log(skip int, level Level, msg string) {
-	if l.encoder == nil || l.writer == nil {
+//Data returns own and inherited data values
+func (l *logger) Data() map[string]interface{} {
+	data := map[string]interface{}{}
+	if l.parent != nil {
+		data = l.parent.Data()
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for n, v := range l.data {
+		data[n] = v
+	}
+	return data
+} //logger.Data()
+
+func (l *logger) log(skip int, level Level, msg string, fields ...Field) {
+	if l.enabled(level) && l.sample(level) {
+		l.emit(GetCaller(skip+4), level, msg, "", fields)
+	}
+}
+
+//emit makes the record and writes it unless it is suppressed
+func (l *logger) emit(caller Caller, level Level, msg, event string, fields []Field) {
+	//gather info for the log record
+	record := Record{
+		Time:    now(),
+		Caller:  normalizeCaller(caller),
+		Level:   level,
+		Message: cleanMessage(msg),
+		Event:   event,
+		Fields:  fields,
+		Data:    l.Data(),
+	}
+	if (l.group != "" || l.deepCopy) && len(fields) > 0 {
+		record.Fields = make([]Field, len(fields))
+		for i, f := range fields {
+			record.Fields[i] = Field{Name: l.key(f.Name), Value: l.copyValue(f.Value)}
+		}
+	}
+	if l.gate != nil && !l.gate(&record) {
+		return
+	}
+	if !l.floodGuard(&record) {
 		return
 	}
-	if level >= l.level {
-		//gather info for the log record
-		cleanMessage := strings.Map(func(r rune) rune {
-			if unicode.IsGraphic(r) {
-				return r
-			}
-			return -1
-		}, msg)
-		record := Record{
-			Time:    time.Now(),
-			Caller:  GetCaller(skip + 4),
-			Level:   level,
-			Message: cleanMessage,
+	l.write(record)
+	l.panicIfError(record)
+} //logger.emit()
+
+//cleanMessage removes escape sequences as a whole so that no "[31m" is
+//left when the escape character is removed as non-graphic
+func cleanMessage(msg string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsGraphic(r) {
+			return r
 		}
+		return -1
+	}, StripANSIText(msg))
+}
 
-		//encode and write it
-		encodedRecord := l.encoder.Encode(l, record)
-		l.writer.Write(encodedRecord)
+//write encodes the record and writes it
+func (l *logger) write(record Record) {
+	w := l.checkSchema(&record)
+	if w == nil {
+		return
 	}
-}
+	if stripANSIFor(w) {
+		record = stripANSIRecord(record)
+	}
+	if q, ok := w.(IRecordQueue); ok {
+		//the writer encodes the record itself
+		if err := q.QueueRecord(l, record); err != nil {
+			handleError(fmt.Errorf("write to %s failed: %v", writerIdentity(w), err))
+		}
+	} else {
+		encodedRecord, err := encode(l.encoder, l, record)
+		if err != nil {
+			handleError(err)
+			return
+		}
+		if _, err := timedWrite(w, l, record, encodedRecord); err != nil {
+			handleError(fmt.Errorf("write to %s failed: %v", writerIdentity(w), err))
+		}
+	}
+	l.count(record)
+	checkAlerts(l, record)
+
+	//do not lose buffered output when the program is about to terminate
+	if record.Level >= PanicLevel {
+		if f, ok := w.(IFlusher); ok {
+			f.Flush()
+		}
+	}
+} //logger.write()
 
 func (l *logger) logf(level Level, format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	l.log(1, level, msg)
 }
 
+func (l *logger) LogDepth(depth int, level Level, msg string, fields ...Field) {
+	l.log(depth, level, msg, fields...)
+}
+
 func (l *logger) Log(level Level, msg string) { l.log(0, level, msg) }
 func (l *logger) Trace(msg string)            { l.log(0, TraceLevel, msg) }
 func (l *logger) Debug(msg string)            { l.log(0, DebugLevel, msg) }
 func (l *logger) Info(msg string)             { l.log(0, InfoLevel, msg) }
 func (l *logger) Warn(msg string)             { l.log(0, WarnLevel, msg) }
 func (l *logger) Error(msg string)            { l.log(0, ErrorLevel, msg) }
-func (l *logger) Fatal(msg string)            { l.log(0, FatalLevel, msg) }
+func (l *logger) Fatal(msg string)            { l.log(0, FatalLevel, msg); l.fatal(msg, nil) }
 
 func (l *logger) Logf(level Level, format string, args ...interface{}) { l.logf(level, format, args...) }
 func (l *logger) Tracef(format string, args ...interface{})            { l.logf(TraceLevel, format, args...) }
@@ -231,7 +498,10 @@ func (l *logger) Debugf(format string, args ...interface{})            { l.logf(
 func (l *logger) Infof(format string, args ...interface{})             { l.logf(InfoLevel, format, args...) }
 func (l *logger) Warnf(format string, args ...interface{})             { l.logf(WarnLevel, format, args...) }
 func (l *logger) Errorf(format string, args ...interface{})            { l.logf(ErrorLevel, format, args...) }
-func (l *logger) Fatalf(format string, args ...interface{})            { l.logf(FatalLevel, format, args...) }
+func (l *logger) Fatalf(format string, args ...interface{}) {
+	l.logf(FatalLevel, format, args...)
+	l.fatal(fmt.Sprintf(format, args...), nil)
+}
 
 func (l *logger) SetLevel(level Level) {
 	if level >= _minLevel && level <= _maxLevel {
@@ -247,20 +517,79 @@ func (l *logger) WithLevel(level Level) ILogger {
 	return l
 }
 
+func (l *logger) Level() Level {
+	return l.level
+}
+
+func (l *logger) SetV(v int) {
+	l.v = v
+	for _, ll := range l.subs {
+		ll.WithV(v)
+	}
+} //logger.SetV()
+
+func (l *logger) WithV(v int) ILogger {
+	l.SetV(v)
+	return l
+}
+
+func (l *logger) V(n int) ILogger {
+	if n <= l.v {
+		return l
+	}
+	return l.discard()
+} //logger.V()
+
+func (l *logger) IfError(err error) ILogger {
+	if err == nil {
+		return l.discard()
+	}
+	sub := l.anon(l.group)
+	sub.data[l.key("error")] = err
+	return sub
+} //logger.IfError()
+
+func (l *logger) IfTrue(cond bool) ILogger {
+	if !cond {
+		return l.discard()
+	}
+	return l
+} //logger.IfTrue()
+
+//discard returns a temp logger without a writer, so it writes nothing
+func (l *logger) discard() *logger {
+	d := l.anon(l.group)
+	d.writer = nil
+	return d
+}
+
 func (l *logger) With(n string, v interface{}) ILogger {
 	if ValidName(n) {
-		if v == nil {
-			delete(l.data, n)
-		} else {
-			l.data[n] = v
-		}
-		for _, ll := range l.subs {
-			ll.With(n, nil) //delete in sub loggers to inherit this value
-		}
+		l.with(l.key(n), l.copyValue(v))
 	}
 	return l
 } //logger.With()
 
+//with sets/deletes the full data name (i.e. already prefixed with the group)
+func (l *logger) with(n string, v interface{}) {
+	l.mutex.Lock()
+	if v == nil {
+		delete(l.data, n)
+	} else {
+		l.data[n] = v
+	}
+	subs := make([]ILogger, 0, len(l.subs))
+	for _, ll := range l.subs {
+		subs = append(subs, ll)
+	}
+	l.mutex.Unlock()
+	for _, ll := range subs {
+		if sl, ok := ll.(*logger); ok {
+			sl.with(n, nil) //delete in sub loggers to inherit this value
+		}
+	}
+} //logger.with()
+
 func (l *logger) SetEncoder(e IEncoder) {
 	if e != nil {
 		l.encoder = e
@@ -277,8 +606,14 @@ func (l *logger) WithEncoder(e IEncoder) ILogger {
 
 func (l *logger) SetWriter(w io.Writer) {
 	if w != nil {
+		l.mutex.Lock()
 		l.writer = w
+		subs := make([]ILogger, 0, len(l.subs))
 		for _, ll := range l.subs {
+			subs = append(subs, ll)
+		}
+		l.mutex.Unlock()
+		for _, ll := range subs {
 			ll.WithWriter(w)
 		}
 	}
@@ -289,6 +624,34 @@ func (l *logger) WithWriter(w io.Writer) ILogger {
 	return l
 }
 
+func (l *logger) Writer() io.Writer {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.writer
+}
+
+//ReplaceWriter sets w on l and its children like l.SetWriter(w), and returns
+//a func that restores the writer each of them had before, e.g. to capture
+//the output of a package in a test
+func ReplaceWriter(l ILogger, w io.Writer) (restore func()) {
+	ll, ok := l.(*logger)
+	if !ok || w == nil {
+		return func() {}
+	}
+	previous := map[*logger]io.Writer{}
+	ll.walk(func(sub *logger) {
+		previous[sub] = sub.Writer()
+	})
+	ll.SetWriter(w)
+	return func() {
+		for sub, pw := range previous {
+			sub.mutex.Lock()
+			sub.writer = pw
+			sub.mutex.Unlock()
+		}
+	}
+} //ReplaceWriter()
+
 //top is the parent of all loggers, allowing any program to discover
 //loggers created in various packages using the same logger library
 //if you modify settings in a parent (like top) then itself and all
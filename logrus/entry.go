@@ -0,0 +1,185 @@
+package logrus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-msvc/log"
+)
+
+//ErrorKey is the field name used by WithError
+var ErrorKey = "error"
+
+//Fields to log with an entry
+type Fields map[string]interface{}
+
+//Entry is a set of fields to log with a message,
+//the fields are written as record fields in the backend ILogger
+type Entry struct {
+	Logger  *Logger
+	Data    Fields
+	Time    time.Time
+	Level   Level
+	Message string
+	Context context.Context
+}
+
+//NewEntry returns an entry without fields
+func NewEntry(logger *Logger) *Entry {
+	return &Entry{
+		Logger: logger,
+		Data:   make(Fields, 6),
+	}
+}
+
+//String is the entry message
+func (entry *Entry) String() string {
+	return entry.Message
+}
+
+//WithError adds the error in field "error"
+func (entry *Entry) WithError(err error) *Entry {
+	return entry.WithField(ErrorKey, err)
+}
+
+//WithContext returns a copy of the entry with the context
+func (entry *Entry) WithContext(ctx context.Context) *Entry {
+	e := entry.copy(0)
+	e.Context = ctx
+	return e
+}
+
+//WithField returns a copy of the entry with one more field
+func (entry *Entry) WithField(key string, value interface{}) *Entry {
+	return entry.WithFields(Fields{key: value})
+}
+
+//WithFields returns a copy of the entry with more fields
+func (entry *Entry) WithFields(fields Fields) *Entry {
+	e := entry.copy(len(fields))
+	for k, v := range fields {
+		e.Data[k] = v
+	}
+	return e
+}
+
+//WithTime returns a copy of the entry with the time overridden
+//note that the backend ILogger still writes the time it logged the record
+func (entry *Entry) WithTime(t time.Time) *Entry {
+	e := entry.copy(0)
+	e.Time = t
+	return e
+}
+
+//copy returns a copy of the entry with its own Data, with room for extra
+//fields, so that hooks and callers do not change the data of other entries
+func (entry *Entry) copy(extra int) *Entry {
+	data := make(Fields, len(entry.Data)+extra)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	return &Entry{
+		Logger:  entry.Logger,
+		Data:    data,
+		Time:    entry.Time,
+		Context: entry.Context,
+	}
+}
+
+//log fires the hooks then writes to the backend, skip is the nr of frames
+//between this function and the caller to report in the log record
+func (entry *Entry) log(skip int, level Level, msg string) {
+	if !entry.Logger.IsLevelEnabled(level) && level > FatalLevel {
+		return
+	}
+	e := entry.copy(0)
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Level = level
+	e.Message = msg
+	entry.Logger.fireHooks(level, e)
+
+	names := make([]string, 0, len(e.Data))
+	for n := range e.Data {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fields := make([]log.Field, len(names))
+	for i, n := range names {
+		fields[i] = log.Field{Name: n, Value: e.Data[n]}
+	}
+	entry.Logger.backend.LogDepth(skip+1, level.toLog(), e.Message, fields...)
+
+	switch level {
+	case FatalLevel:
+		entry.Logger.ExitFunc(1)
+	case PanicLevel:
+		panic(e)
+	}
+} //Entry.log()
+
+//Log logs at the specified level
+func (entry *Entry) Log(level Level, args ...interface{}) {
+	entry.log(1, level, fmt.Sprint(args...))
+}
+
+//Logf logs at the specified level
+func (entry *Entry) Logf(level Level, format string, args ...interface{}) {
+	entry.log(1, level, fmt.Sprintf(format, args...))
+}
+
+//Logln logs at the specified level
+func (entry *Entry) Logln(level Level, args ...interface{}) {
+	entry.log(1, level, sprintlnn(args...))
+}
+
+func (entry *Entry) Trace(args ...interface{})   { entry.log(1, TraceLevel, fmt.Sprint(args...)) }
+func (entry *Entry) Debug(args ...interface{})   { entry.log(1, DebugLevel, fmt.Sprint(args...)) }
+func (entry *Entry) Info(args ...interface{})    { entry.log(1, InfoLevel, fmt.Sprint(args...)) }
+func (entry *Entry) Print(args ...interface{})   { entry.log(1, InfoLevel, fmt.Sprint(args...)) }
+func (entry *Entry) Warn(args ...interface{})    { entry.log(1, WarnLevel, fmt.Sprint(args...)) }
+func (entry *Entry) Warning(args ...interface{}) { entry.log(1, WarnLevel, fmt.Sprint(args...)) }
+func (entry *Entry) Error(args ...interface{})   { entry.log(1, ErrorLevel, fmt.Sprint(args...)) }
+func (entry *Entry) Fatal(args ...interface{})   { entry.log(1, FatalLevel, fmt.Sprint(args...)) }
+func (entry *Entry) Panic(args ...interface{})   { entry.log(1, PanicLevel, fmt.Sprint(args...)) }
+
+func (entry *Entry) Tracef(format string, args ...interface{}) {
+	entry.log(1, TraceLevel, fmt.Sprintf(format, args...))
+}
+func (entry *Entry) Debugf(format string, args ...interface{}) {
+	entry.log(1, DebugLevel, fmt.Sprintf(format, args...))
+}
+func (entry *Entry) Infof(format string, args ...interface{}) {
+	entry.log(1, InfoLevel, fmt.Sprintf(format, args...))
+}
+func (entry *Entry) Printf(format string, args ...interface{}) {
+	entry.log(1, InfoLevel, fmt.Sprintf(format, args...))
+}
+func (entry *Entry) Warnf(format string, args ...interface{}) {
+	entry.log(1, WarnLevel, fmt.Sprintf(format, args...))
+}
+func (entry *Entry) Warningf(format string, args ...interface{}) {
+	entry.log(1, WarnLevel, fmt.Sprintf(format, args...))
+}
+func (entry *Entry) Errorf(format string, args ...interface{}) {
+	entry.log(1, ErrorLevel, fmt.Sprintf(format, args...))
+}
+func (entry *Entry) Fatalf(format string, args ...interface{}) {
+	entry.log(1, FatalLevel, fmt.Sprintf(format, args...))
+}
+func (entry *Entry) Panicf(format string, args ...interface{}) {
+	entry.log(1, PanicLevel, fmt.Sprintf(format, args...))
+}
+
+func (entry *Entry) Traceln(args ...interface{})   { entry.log(1, TraceLevel, sprintlnn(args...)) }
+func (entry *Entry) Debugln(args ...interface{})   { entry.log(1, DebugLevel, sprintlnn(args...)) }
+func (entry *Entry) Infoln(args ...interface{})    { entry.log(1, InfoLevel, sprintlnn(args...)) }
+func (entry *Entry) Println(args ...interface{})   { entry.log(1, InfoLevel, sprintlnn(args...)) }
+func (entry *Entry) Warnln(args ...interface{})    { entry.log(1, WarnLevel, sprintlnn(args...)) }
+func (entry *Entry) Warningln(args ...interface{}) { entry.log(1, WarnLevel, sprintlnn(args...)) }
+func (entry *Entry) Errorln(args ...interface{})   { entry.log(1, ErrorLevel, sprintlnn(args...)) }
+func (entry *Entry) Fatalln(args ...interface{})   { entry.log(1, FatalLevel, sprintlnn(args...)) }
+func (entry *Entry) Panicln(args ...interface{})   { entry.log(1, PanicLevel, sprintlnn(args...)) }
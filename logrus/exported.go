@@ -0,0 +1,96 @@
+//Package logrus offers the API of github.com/sirupsen/logrus backed by
+//a github.com/go-msvc/log ILogger, so code written for logrus can switch
+//the backend by only changing the import path. The output is encoded by the
+//ILogger encoder, which SetFormatter() can set for logrus formatters.
+package logrus
+
+import (
+	"fmt"
+	"io"
+)
+
+//std is the logger used by the package functions
+var std = New()
+
+//StandardLogger returns the logger used by the package functions
+func StandardLogger() *Logger {
+	return std
+}
+
+//SetOutput sets the writer of the standard logger
+func SetOutput(out io.Writer) { std.SetOutput(out) }
+
+//SetLevel sets the level of the standard logger
+func SetLevel(level Level) { std.SetLevel(level) }
+
+//GetLevel returns the level of the standard logger
+func GetLevel() Level { return std.GetLevel() }
+
+//IsLevelEnabled is true if the standard logger logs at the level
+func IsLevelEnabled(level Level) bool { return std.IsLevelEnabled(level) }
+
+//SetFormatter sets the encoder of the standard logger for the formatter
+func SetFormatter(formatter Formatter) { std.SetFormatter(formatter) }
+
+//SetReportCaller sets if the standard logger writes the caller of entries
+func SetReportCaller(reportCaller bool) { std.SetReportCaller(reportCaller) }
+
+//AddHook adds a hook to the standard logger
+func AddHook(hook Hook) { std.AddHook(hook) }
+
+//WithError returns an entry of the standard logger with the error in field "error"
+func WithError(err error) *Entry { return std.WithError(err) }
+
+//WithField returns an entry of the standard logger with one field
+func WithField(key string, value interface{}) *Entry { return std.WithField(key, value) }
+
+//WithFields returns an entry of the standard logger with the fields
+func WithFields(fields Fields) *Entry { return std.WithFields(fields) }
+
+func Trace(args ...interface{})   { std.newEntry().log(1, TraceLevel, fmt.Sprint(args...)) }
+func Debug(args ...interface{})   { std.newEntry().log(1, DebugLevel, fmt.Sprint(args...)) }
+func Info(args ...interface{})    { std.newEntry().log(1, InfoLevel, fmt.Sprint(args...)) }
+func Print(args ...interface{})   { std.newEntry().log(1, InfoLevel, fmt.Sprint(args...)) }
+func Warn(args ...interface{})    { std.newEntry().log(1, WarnLevel, fmt.Sprint(args...)) }
+func Warning(args ...interface{}) { std.newEntry().log(1, WarnLevel, fmt.Sprint(args...)) }
+func Error(args ...interface{})   { std.newEntry().log(1, ErrorLevel, fmt.Sprint(args...)) }
+func Fatal(args ...interface{})   { std.newEntry().log(1, FatalLevel, fmt.Sprint(args...)) }
+func Panic(args ...interface{})   { std.newEntry().log(1, PanicLevel, fmt.Sprint(args...)) }
+
+func Tracef(format string, args ...interface{}) {
+	std.newEntry().log(1, TraceLevel, fmt.Sprintf(format, args...))
+}
+func Debugf(format string, args ...interface{}) {
+	std.newEntry().log(1, DebugLevel, fmt.Sprintf(format, args...))
+}
+func Infof(format string, args ...interface{}) {
+	std.newEntry().log(1, InfoLevel, fmt.Sprintf(format, args...))
+}
+func Printf(format string, args ...interface{}) {
+	std.newEntry().log(1, InfoLevel, fmt.Sprintf(format, args...))
+}
+func Warnf(format string, args ...interface{}) {
+	std.newEntry().log(1, WarnLevel, fmt.Sprintf(format, args...))
+}
+func Warningf(format string, args ...interface{}) {
+	std.newEntry().log(1, WarnLevel, fmt.Sprintf(format, args...))
+}
+func Errorf(format string, args ...interface{}) {
+	std.newEntry().log(1, ErrorLevel, fmt.Sprintf(format, args...))
+}
+func Fatalf(format string, args ...interface{}) {
+	std.newEntry().log(1, FatalLevel, fmt.Sprintf(format, args...))
+}
+func Panicf(format string, args ...interface{}) {
+	std.newEntry().log(1, PanicLevel, fmt.Sprintf(format, args...))
+}
+
+func Traceln(args ...interface{})   { std.newEntry().log(1, TraceLevel, sprintlnn(args...)) }
+func Debugln(args ...interface{})   { std.newEntry().log(1, DebugLevel, sprintlnn(args...)) }
+func Infoln(args ...interface{})    { std.newEntry().log(1, InfoLevel, sprintlnn(args...)) }
+func Println(args ...interface{})   { std.newEntry().log(1, InfoLevel, sprintlnn(args...)) }
+func Warnln(args ...interface{})    { std.newEntry().log(1, WarnLevel, sprintlnn(args...)) }
+func Warningln(args ...interface{}) { std.newEntry().log(1, WarnLevel, sprintlnn(args...)) }
+func Errorln(args ...interface{})   { std.newEntry().log(1, ErrorLevel, sprintlnn(args...)) }
+func Fatalln(args ...interface{})   { std.newEntry().log(1, FatalLevel, sprintlnn(args...)) }
+func Panicln(args ...interface{})   { std.newEntry().log(1, PanicLevel, sprintlnn(args...)) }
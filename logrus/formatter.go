@@ -0,0 +1,120 @@
+package logrus
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-msvc/log"
+)
+
+//Formatter formats an entry as logrus formatters do. The JSONFormatter and
+//TextFormatter of this package are mapped to the JSON and console encoders
+//of the backend ILogger, other formatters are called for each record.
+type Formatter interface {
+	Format(*Entry) ([]byte, error)
+}
+
+//JSONFormatter selects the JSON encoder of the backend ILogger
+type JSONFormatter struct {
+	//TimestampFormat is the time layout, default time.RFC3339Nano
+	TimestampFormat string
+	//DisableTimestamp omits the time
+	DisableTimestamp bool
+}
+
+//Format encodes the entry with the JSON encoder
+func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
+	return f.encoder(false).Encode(entry.Logger.backend, entry.record()), nil
+}
+
+func (f *JSONFormatter) encoder(reportCaller bool) log.IEncoder {
+	e := log.NewJSONEncoder()
+	if f.TimestampFormat != "" {
+		e = e.WithTimeFormat(f.TimestampFormat)
+	}
+	if f.DisableTimestamp {
+		e = e.WithKey("time", "")
+	}
+	if !reportCaller {
+		e = e.WithKey("caller", "")
+	}
+	return e
+}
+
+//TextFormatter selects the console encoder of the backend ILogger with
+//the fields of each entry. Colors are only written when the console
+//supports them (see log.ColorEnabled()), also with ForceColors.
+type TextFormatter struct {
+	ForceColors      bool
+	DisableColors    bool
+	DisableTimestamp bool
+	//TimestampFormat is the time layout, default "2006-01-02 15:04:05.000"
+	TimestampFormat string
+}
+
+//Format encodes the entry with the console encoder
+func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
+	return f.encoder(false).Encode(entry.Logger.backend, entry.record()), nil
+}
+
+func (f *TextFormatter) encoder(reportCaller bool) log.IEncoder {
+	opts := []log.EncoderOption{log.EncoderWithFields()}
+	if !f.DisableColors {
+		opts = append(opts, log.EncoderWithColor())
+	}
+	if f.TimestampFormat != "" {
+		opts = append(opts, log.EncoderWithTimeFormat(f.TimestampFormat))
+	}
+	if f.DisableTimestamp {
+		opts = append(opts, func(ce log.IColumnEncoder) log.IColumnEncoder {
+			return ce.Remove("time")
+		})
+	}
+	if !reportCaller {
+		opts = append(opts, log.EncoderWithoutCaller())
+	}
+	return log.DefaultEncoder(opts...)
+}
+
+//formatterEncoder calls a custom formatter for each record
+type formatterEncoder struct {
+	logger    *Logger
+	formatter Formatter
+}
+
+func (e formatterEncoder) Encode(l log.ILogger, r log.Record) []byte {
+	entry := NewEntry(e.logger)
+	entry.Time = r.Time
+	entry.Level = fromLog(r.Level)
+	entry.Message = r.Message
+	for n, v := range log.RecordData(l, r) {
+		entry.Data[n] = v
+	}
+	b, err := e.formatter.Format(entry)
+	if err != nil {
+		//the encoder error is reported by the backend
+		panic(err)
+	}
+	return b
+}
+
+//record returns the entry as a record for the backend encoders
+func (entry *Entry) record() log.Record {
+	r := log.Record{
+		Time:    entry.Time,
+		Level:   entry.Level.toLog(),
+		Message: entry.Message,
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	names := make([]string, 0, len(entry.Data))
+	for n := range entry.Data {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		r.Fields = append(r.Fields, log.Field{Name: n, Value: entry.Data[n]})
+	}
+	return r
+}
@@ -0,0 +1,110 @@
+package logrus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-msvc/log"
+)
+
+//upperFormatter is a custom formatter
+type upperFormatter struct{}
+
+func (upperFormatter) Format(entry *Entry) ([]byte, error) {
+	return []byte(strings.ToUpper(entry.Message) + " " + entry.Data["k"].(string) + "\n"), nil
+}
+
+func TestSetFormatter(t *testing.T) {
+	tests := []struct {
+		name         string
+		formatter    Formatter
+		reportCaller bool
+		check        func(t *testing.T, out string)
+	}{
+		{"json", &JSONFormatter{}, false, func(t *testing.T, out string) {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(out), &m); err != nil {
+				t.Fatalf("invalid JSON %q: %v", out, err)
+			}
+			if m["message"] != "hello" || m["k"] != "v" || m["caller"] != nil {
+				t.Fatalf("unexpected record %q", out)
+			}
+		}},
+		{"json with caller", &JSONFormatter{DisableTimestamp: true}, true, func(t *testing.T, out string) {
+			if !strings.Contains(out, `"caller":`) || strings.Contains(out, `"time":`) {
+				t.Fatalf("unexpected record %q", out)
+			}
+		}},
+		{"text", &TextFormatter{DisableColors: true}, false, func(t *testing.T, out string) {
+			if !strings.Contains(out, "hello") || !strings.Contains(out, "k=v") || strings.Contains(out, "formatter_test.go") {
+				t.Fatalf("unexpected record %q", out)
+			}
+		}},
+		{"custom", upperFormatter{}, false, func(t *testing.T, out string) {
+			if out != "HELLO v\n" {
+				t.Fatalf("unexpected record %q", out)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			logger := NewWith(log.Top().Temp("logrustest").WithWriter(buf))
+			logger.SetFormatter(tt.formatter)
+			logger.SetReportCaller(tt.reportCaller)
+			logger.WithField("k", "v").Info("hello")
+			tt.check(t, buf.String())
+		})
+	}
+}
+
+//dataFormatter writes the entry data
+type dataFormatter struct{}
+
+func (dataFormatter) Format(entry *Entry) ([]byte, error) {
+	return []byte(fmt.Sprintf("%v\n", map[string]interface{}(entry.Data))), nil
+}
+
+func TestCustomFormatterGetsLoggerData(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	logger := NewWith(log.Top().Temp("logrustest").WithWriter(buf).With("service", "billing"))
+	logger.SetFormatter(dataFormatter{})
+	logger.WithField("k", "v").Info("hello")
+	if out := buf.String(); out != "map[k:v service:billing]\n" {
+		t.Fatalf("unexpected record %q", out)
+	}
+}
+
+//fieldHook adds a field to each entry
+type fieldHook struct{}
+
+func (fieldHook) Levels() []Level { return AllLevels }
+func (fieldHook) Fire(entry *Entry) error {
+	entry.Data["hooked"] = true
+	return nil
+}
+
+func TestHooksDoNotChangeCallerEntry(t *testing.T) {
+	logger := NewWith(log.Top().Temp("logrustest").WithWriter(io.Discard))
+	logger.AddHook(fieldHook{})
+	entry := logger.WithField("k", "v")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				entry.Info("hello")
+			}
+		}()
+	}
+	wg.Wait()
+	if _, ok := entry.Data["hooked"]; ok || len(entry.Data) != 1 {
+		t.Fatalf("hook changed the entry data %v", entry.Data)
+	}
+}
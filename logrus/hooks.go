@@ -0,0 +1,27 @@
+package logrus
+
+//Hook is fired when an entry is logged at one of its levels
+type Hook interface {
+	Levels() []Level
+	Fire(*Entry) error
+}
+
+//LevelHooks lists the hooks for each level
+type LevelHooks map[Level][]Hook
+
+//Add a hook to all its levels
+func (hooks LevelHooks) Add(hook Hook) {
+	for _, level := range hook.Levels() {
+		hooks[level] = append(hooks[level], hook)
+	}
+}
+
+//Fire all the hooks for the level
+func (hooks LevelHooks) Fire(level Level, entry *Entry) error {
+	for _, hook := range hooks[level] {
+		if err := hook.Fire(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package logrus
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-msvc/log"
+)
+
+//Level is a logrus level, where lower values are more important
+type Level uint32
+
+const (
+	//PanicLevel logs then panics
+	PanicLevel Level = iota
+	//FatalLevel logs then calls the logger's ExitFunc (os.Exit(1) by default)
+	FatalLevel
+	//ErrorLevel is used for errors that should be noted
+	ErrorLevel
+	//WarnLevel is for non-critical entries that deserve eyes
+	WarnLevel
+	//InfoLevel is for general operational entries
+	InfoLevel
+	//DebugLevel is for verbose logging
+	DebugLevel
+	//TraceLevel is for finer-grained informational events than debug
+	TraceLevel
+)
+
+//AllLevels lists all levels from most to least important
+var AllLevels = []Level{
+	PanicLevel,
+	FatalLevel,
+	ErrorLevel,
+	WarnLevel,
+	InfoLevel,
+	DebugLevel,
+	TraceLevel,
+}
+
+//String value of the level in lowercase
+func (level Level) String() string {
+	switch level {
+	case PanicLevel:
+		return "panic"
+	case FatalLevel:
+		return "fatal"
+	case ErrorLevel:
+		return "error"
+	case WarnLevel:
+		return "warning"
+	case InfoLevel:
+		return "info"
+	case DebugLevel:
+		return "debug"
+	case TraceLevel:
+		return "trace"
+	}
+	return "unknown"
+}
+
+//ParseLevel takes a string level and returns the level
+func ParseLevel(lvl string) (Level, error) {
+	switch strings.ToLower(lvl) {
+	case "panic":
+		return PanicLevel, nil
+	case "fatal":
+		return FatalLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "debug":
+		return DebugLevel, nil
+	case "trace":
+		return TraceLevel, nil
+	}
+	return PanicLevel, fmt.Errorf("not a valid logrus Level: %q", lvl)
+}
+
+//UnmarshalText implements encoding.TextUnmarshaler
+func (level *Level) UnmarshalText(text []byte) error {
+	l, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*level = l
+	return nil
+}
+
+//MarshalText implements encoding.TextMarshaler
+func (level Level) MarshalText() ([]byte, error) {
+	switch level {
+	case PanicLevel, FatalLevel, ErrorLevel, WarnLevel, InfoLevel, DebugLevel, TraceLevel:
+		return []byte(level.String()), nil
+	}
+	return nil, fmt.Errorf("not a valid logrus level %d", level)
+}
+
+//toLog converts to the backend level
+func (level Level) toLog() log.Level {
+	switch level {
+	case PanicLevel:
+		return log.PanicLevel
+	case FatalLevel:
+		return log.FatalLevel
+	case ErrorLevel:
+		return log.ErrorLevel
+	case WarnLevel:
+		return log.WarnLevel
+	case InfoLevel:
+		return log.InfoLevel
+	case DebugLevel:
+		return log.DebugLevel
+	}
+	return log.TraceLevel
+}
+
+//fromLog converts from the backend level
+func fromLog(level log.Level) Level {
+	switch {
+	case level >= log.FatalLevel:
+		return FatalLevel
+	case level >= log.PanicLevel:
+		return PanicLevel
+	case level >= log.ErrorLevel:
+		return ErrorLevel
+	case level >= log.WarnLevel:
+		return WarnLevel
+	case level >= log.InfoLevel:
+		return InfoLevel
+	case level >= log.DebugLevel:
+		return DebugLevel
+	}
+	return TraceLevel
+}
@@ -0,0 +1,247 @@
+package logrus
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/go-msvc/log"
+)
+
+//Logger has the same API as a logrus logger but writes all entries
+//to an ILogger, so the level, encoder and writer are those of the ILogger
+type Logger struct {
+	//Hooks fired for each entry before it is logged
+	Hooks LevelHooks
+	//ExitFunc is called after logging at FatalLevel, default os.Exit
+	ExitFunc func(int)
+
+	backend log.ILogger
+	mutex   sync.Mutex
+	//formatter and reportCaller select the encoder of the backend
+	formatter    Formatter
+	reportCaller bool
+}
+
+//New returns a logger that writes to the top logger
+func New() *Logger {
+	return NewWith(log.Top())
+}
+
+//NewWith returns a logger that writes to the specified ILogger
+func NewWith(l log.ILogger) *Logger {
+	return &Logger{
+		Hooks:    LevelHooks{},
+		ExitFunc: os.Exit,
+		backend:  l,
+	}
+}
+
+//Backend is the ILogger that the entries are written to
+func (logger *Logger) Backend() log.ILogger {
+	return logger.backend
+}
+
+//SetLevel sets the level of the backend ILogger and its children
+func (logger *Logger) SetLevel(level Level) {
+	logger.backend.SetLevel(level.toLog())
+}
+
+//GetLevel returns the level of the backend ILogger
+func (logger *Logger) GetLevel() Level {
+	return fromLog(logger.backend.Level())
+}
+
+//IsLevelEnabled is true if entries at the level will be logged
+func (logger *Logger) IsLevelEnabled(level Level) bool {
+	return level.toLog() >= logger.backend.Level()
+}
+
+//SetOutput sets the writer of the backend ILogger and its children
+func (logger *Logger) SetOutput(output io.Writer) {
+	logger.backend.SetWriter(output)
+}
+
+//SetFormatter sets the encoder of the backend ILogger and its children:
+//the JSON encoder for a *JSONFormatter, the console encoder for a
+//*TextFormatter, or an encoder that calls the formatter for others
+func (logger *Logger) SetFormatter(formatter Formatter) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.formatter = formatter
+	logger.setEncoder()
+}
+
+//SetReportCaller writes the caller of each entry, which is only used by
+//the JSONFormatter and TextFormatter: without SetFormatter() the encoder
+//of the backend ILogger decides if the caller is written
+func (logger *Logger) SetReportCaller(reportCaller bool) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.reportCaller = reportCaller
+	logger.setEncoder()
+}
+
+//setEncoder sets the encoder for the formatter, the caller must hold the mutex
+func (logger *Logger) setEncoder() {
+	switch f := logger.formatter.(type) {
+	case nil:
+	case *JSONFormatter:
+		logger.backend.SetEncoder(f.encoder(logger.reportCaller))
+	case *TextFormatter:
+		logger.backend.SetEncoder(f.encoder(logger.reportCaller))
+	default:
+		logger.backend.SetEncoder(formatterEncoder{logger: logger, formatter: f})
+	}
+}
+
+//AddHook adds a hook to the logger
+func (logger *Logger) AddHook(hook Hook) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.Hooks.Add(hook)
+}
+
+//ReplaceHooks replaces the logger hooks and returns the old ones
+func (logger *Logger) ReplaceHooks(hooks LevelHooks) LevelHooks {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	old := logger.Hooks
+	logger.Hooks = hooks
+	return old
+}
+
+//fireHooks is called for each entry before it is written
+func (logger *Logger) fireHooks(level Level, entry *Entry) {
+	logger.mutex.Lock()
+	hooks := logger.Hooks
+	logger.mutex.Unlock()
+	if err := hooks.Fire(level, entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+	}
+}
+
+func (logger *Logger) newEntry() *Entry {
+	return NewEntry(logger)
+}
+
+//WithField returns an entry with one field
+func (logger *Logger) WithField(key string, value interface{}) *Entry {
+	return logger.newEntry().WithField(key, value)
+}
+
+//WithFields returns an entry with the fields
+func (logger *Logger) WithFields(fields Fields) *Entry {
+	return logger.newEntry().WithFields(fields)
+}
+
+//WithError returns an entry with the error in field "error"
+func (logger *Logger) WithError(err error) *Entry {
+	return logger.newEntry().WithError(err)
+}
+
+//Log logs at the specified level
+func (logger *Logger) Log(level Level, args ...interface{}) {
+	logger.newEntry().log(1, level, fmt.Sprint(args...))
+}
+
+//Logf logs at the specified level
+func (logger *Logger) Logf(level Level, format string, args ...interface{}) {
+	logger.newEntry().log(1, level, fmt.Sprintf(format, args...))
+}
+
+//Logln logs at the specified level
+func (logger *Logger) Logln(level Level, args ...interface{}) {
+	logger.newEntry().log(1, level, sprintlnn(args...))
+}
+
+func (logger *Logger) Trace(args ...interface{}) {
+	logger.newEntry().log(1, TraceLevel, fmt.Sprint(args...))
+}
+func (logger *Logger) Debug(args ...interface{}) {
+	logger.newEntry().log(1, DebugLevel, fmt.Sprint(args...))
+}
+func (logger *Logger) Info(args ...interface{}) {
+	logger.newEntry().log(1, InfoLevel, fmt.Sprint(args...))
+}
+func (logger *Logger) Print(args ...interface{}) {
+	logger.newEntry().log(1, InfoLevel, fmt.Sprint(args...))
+}
+func (logger *Logger) Warn(args ...interface{}) {
+	logger.newEntry().log(1, WarnLevel, fmt.Sprint(args...))
+}
+func (logger *Logger) Warning(args ...interface{}) {
+	logger.newEntry().log(1, WarnLevel, fmt.Sprint(args...))
+}
+func (logger *Logger) Error(args ...interface{}) {
+	logger.newEntry().log(1, ErrorLevel, fmt.Sprint(args...))
+}
+func (logger *Logger) Fatal(args ...interface{}) {
+	logger.newEntry().log(1, FatalLevel, fmt.Sprint(args...))
+}
+func (logger *Logger) Panic(args ...interface{}) {
+	logger.newEntry().log(1, PanicLevel, fmt.Sprint(args...))
+}
+
+func (logger *Logger) Tracef(format string, args ...interface{}) {
+	logger.newEntry().log(1, TraceLevel, fmt.Sprintf(format, args...))
+}
+func (logger *Logger) Debugf(format string, args ...interface{}) {
+	logger.newEntry().log(1, DebugLevel, fmt.Sprintf(format, args...))
+}
+func (logger *Logger) Infof(format string, args ...interface{}) {
+	logger.newEntry().log(1, InfoLevel, fmt.Sprintf(format, args...))
+}
+func (logger *Logger) Printf(format string, args ...interface{}) {
+	logger.newEntry().log(1, InfoLevel, fmt.Sprintf(format, args...))
+}
+func (logger *Logger) Warnf(format string, args ...interface{}) {
+	logger.newEntry().log(1, WarnLevel, fmt.Sprintf(format, args...))
+}
+func (logger *Logger) Warningf(format string, args ...interface{}) {
+	logger.newEntry().log(1, WarnLevel, fmt.Sprintf(format, args...))
+}
+func (logger *Logger) Errorf(format string, args ...interface{}) {
+	logger.newEntry().log(1, ErrorLevel, fmt.Sprintf(format, args...))
+}
+func (logger *Logger) Fatalf(format string, args ...interface{}) {
+	logger.newEntry().log(1, FatalLevel, fmt.Sprintf(format, args...))
+}
+func (logger *Logger) Panicf(format string, args ...interface{}) {
+	logger.newEntry().log(1, PanicLevel, fmt.Sprintf(format, args...))
+}
+
+func (logger *Logger) Traceln(args ...interface{}) {
+	logger.newEntry().log(1, TraceLevel, sprintlnn(args...))
+}
+func (logger *Logger) Debugln(args ...interface{}) {
+	logger.newEntry().log(1, DebugLevel, sprintlnn(args...))
+}
+func (logger *Logger) Infoln(args ...interface{}) {
+	logger.newEntry().log(1, InfoLevel, sprintlnn(args...))
+}
+func (logger *Logger) Println(args ...interface{}) {
+	logger.newEntry().log(1, InfoLevel, sprintlnn(args...))
+}
+func (logger *Logger) Warnln(args ...interface{}) {
+	logger.newEntry().log(1, WarnLevel, sprintlnn(args...))
+}
+func (logger *Logger) Warningln(args ...interface{}) {
+	logger.newEntry().log(1, WarnLevel, sprintlnn(args...))
+}
+func (logger *Logger) Errorln(args ...interface{}) {
+	logger.newEntry().log(1, ErrorLevel, sprintlnn(args...))
+}
+func (logger *Logger) Fatalln(args ...interface{}) {
+	logger.newEntry().log(1, FatalLevel, sprintlnn(args...))
+}
+func (logger *Logger) Panicln(args ...interface{}) {
+	logger.newEntry().log(1, PanicLevel, sprintlnn(args...))
+}
+
+//sprintlnn is fmt.Sprintln (always spaces between operands) without the newline
+func sprintlnn(args ...interface{}) string {
+	msg := fmt.Sprintln(args...)
+	return msg[:len(msg)-1]
+}
@@ -0,0 +1,69 @@
+//Package logtest writes the output of loggers to the log of a test
+package logtest
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-msvc/log"
+)
+
+//NewTBWriter returns a writer that writes each record with t.Log, or with
+//t.Error for records at ErrorLevel and above, so that package logs appear
+//interleaved with the test output and only when the test fails (or with
+//go test -v). Each line starts with the file and line of the code that
+//logged the record, rather than the file and line of this writer that
+//the testing package prints. Records written after the test completed
+//are discarded.
+func NewTBWriter(t testing.TB) io.Writer {
+	tw := &tbWriter{t: t}
+	t.Cleanup(func() {
+		tw.mutex.Lock()
+		defer tw.mutex.Unlock()
+		tw.done = true
+	})
+	return tw
+}
+
+//Use sets a TB writer on the logger (and its children) for the duration
+//of the test, and restores the previous writer of each of them when the
+//test completed
+func Use(t testing.TB, l log.ILogger) {
+	t.Cleanup(log.ReplaceWriter(l, NewTBWriter(t)))
+}
+
+//tbWriter implements io.Writer and log.IRecordWriter
+type tbWriter struct {
+	mutex sync.Mutex
+	t     testing.TB
+	done  bool
+}
+
+//Write is used for output without a record
+func (tw *tbWriter) Write(p []byte) (int, error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if !tw.done {
+		tw.t.Log(strings.TrimRight(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+func (tw *tbWriter) WriteRecord(l log.ILogger, r log.Record, encoded []byte) (int, error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if tw.done {
+		return len(encoded), nil
+	}
+	text := fmt.Sprintf("%s:%d: %s", path.Base(r.Caller.File), r.Caller.Line, strings.TrimRight(string(encoded), "\n"))
+	if r.Level >= log.ErrorLevel {
+		tw.t.Error(text)
+	} else {
+		tw.t.Log(text)
+	}
+	return len(encoded), nil
+}
@@ -0,0 +1,81 @@
+package logtest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-msvc/log"
+)
+
+//fakeTB records what the writer passes to the test
+type fakeTB struct {
+	testing.TB
+	logs     []string
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Log(args ...interface{})   { f.logs = append(f.logs, fmt.Sprint(args...)) }
+func (f *fakeTB) Error(args ...interface{}) { f.errors = append(f.errors, fmt.Sprint(args...)) }
+func (f *fakeTB) Cleanup(fn func())         { f.cleanups = append(f.cleanups, fn) }
+
+func (f *fakeTB) cleanup() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestTBWriter(t *testing.T) {
+	tests := []struct {
+		name       string
+		log        func(l log.ILogger)
+		wantLogs   int
+		wantErrors int
+	}{
+		{"info", func(l log.ILogger) { l.Infof("hello") }, 1, 0},
+		{"error", func(l log.ILogger) { l.Errorf("failed") }, 0, 1},
+		{"plain write", func(l log.ILogger) { l.Writer().Write([]byte("text\n")) }, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			l := log.Top().Temp("tbtest").WithWriter(NewTBWriter(tb))
+			tt.log(l)
+			if len(tb.logs) != tt.wantLogs || len(tb.errors) != tt.wantErrors {
+				t.Fatalf("logs %q, errors %q", tb.logs, tb.errors)
+			}
+			for _, line := range append(tb.logs, tb.errors...) {
+				if tt.name != "plain write" && !strings.HasPrefix(line, "tb-writer_test.go:") {
+					t.Fatalf("line %q does not start with the caller", line)
+				}
+			}
+			//records after the test completed are discarded
+			tb.cleanup()
+			tt.log(l)
+			if len(tb.logs) != tt.wantLogs || len(tb.errors) != tt.wantErrors {
+				t.Fatalf("logged after cleanup: logs %q, errors %q", tb.logs, tb.errors)
+			}
+		})
+	}
+}
+
+func TestUseRestoresWriters(t *testing.T) {
+	parentBuf, childBuf := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	parent := log.Top().Logger("usetest").WithWriter(parentBuf)
+	child := parent.Logger("child").WithWriter(childBuf)
+
+	tb := &fakeTB{}
+	Use(tb, parent)
+	parent.Infof("to test")
+	child.Infof("to test")
+	if len(tb.logs) != 2 || parentBuf.Len() > 0 || childBuf.Len() > 0 {
+		t.Fatalf("logs %q, parent %q, child %q", tb.logs, parentBuf, childBuf)
+	}
+
+	tb.cleanup()
+	if parent.Writer() != parentBuf || child.Writer() != childBuf {
+		t.Fatal("writers not restored")
+	}
+}
@@ -0,0 +1,132 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//MessageTemplateField is the name of the field with the template of a
+//message logged with Logt() etc, so that records can be grouped by template
+const MessageTemplateField = "message_template"
+
+//messageTemplate is a parsed template, e.g. "user {user} bought {count} items"
+//with text ["user ", " bought ", " items"] and names ["user", "count"]
+type messageTemplate struct {
+	text  []string
+	names []string
+}
+
+var messageTemplates sync.Map //template string -> *messageTemplate
+
+//parseMessageTemplate parses and caches a template, where {name} is a
+//placeholder and {{ and }} are written as { and }
+func parseMessageTemplate(template string) *messageTemplate {
+	if mt, ok := messageTemplates.Load(template); ok {
+		return mt.(*messageTemplate)
+	}
+	mt := &messageTemplate{}
+	text := ""
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if (c == '{' || c == '}') && i+1 < len(template) && template[i+1] == c {
+			text += string(c)
+			i++
+			continue
+		}
+		if c == '{' {
+			if end := strings.IndexByte(template[i:], '}'); end > 1 {
+				mt.text = append(mt.text, text)
+				mt.names = append(mt.names, template[i+1:i+end])
+				text = ""
+				i += end
+				continue
+			}
+		}
+		text += string(c)
+	}
+	mt.text = append(mt.text, text)
+	messageTemplates.Store(template, mt)
+	return mt
+} //parseMessageTemplate()
+
+//render returns the message with the args in the placeholders, and the
+//args as fields named after the placeholders, plus the template field
+//placeholders without args are written as is, and args without placeholders
+//or with a placeholder that is not a valid field name are added as fields
+//"arg<n>"
+func (mt *messageTemplate) render(template string, args []interface{}) (string, []Field) {
+	fields := make([]Field, 0, len(args)+1)
+	for i := range args {
+		name := ""
+		if i < len(mt.names) {
+			name = mt.names[i]
+		}
+		fields = append(fields, Field{Name: templateFieldName(i, name), Value: args[i]})
+	}
+	msg := mt.message(func(i int) (string, bool) {
+		if i < len(args) {
+			return fmt.Sprint(args[i]), true
+		}
+		return "", false
+	})
+	fields = append(fields, Field{Name: MessageTemplateField, Value: template})
+	return msg, fields
+} //messageTemplate.render()
+
+//message returns the text with the value of each placeholder, or the
+//placeholder as is when it has no value
+func (mt *messageTemplate) message(value func(i int) (string, bool)) string {
+	msg := mt.text[0]
+	for i, name := range mt.names {
+		if v, ok := value(i); ok {
+			msg += v
+		} else {
+			msg += "{" + name + "}"
+		}
+		msg += mt.text[i+1]
+	}
+	return msg
+} //messageTemplate.message()
+
+//templateFieldName is the name of the field with arg i of a template
+func templateFieldName(i int, placeholder string) string {
+	if ValidName(placeholder) {
+		return placeholder
+	}
+	return fmt.Sprintf("arg%d", i)
+}
+
+//recordTemplate returns the template of a record logged with Logt() etc,
+//and the prefix of the field names when it was logged in a group
+func recordTemplate(r Record) (template, prefix string, ok bool) {
+	for _, f := range r.Fields {
+		if strings.HasSuffix(f.Name, MessageTemplateField) {
+			if template, ok := f.Value.(string); ok {
+				return template, strings.TrimSuffix(f.Name, MessageTemplateField), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func (l *logger) logt(level Level, template string, args ...interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	msg, fields := parseMessageTemplate(template).render(template, args)
+	l.log(1, level, msg, fields...)
+	if level == FatalLevel {
+		l.fatal(msg, nil)
+	}
+}
+
+func (l *logger) Logt(level Level, template string, args ...interface{}) {
+	l.logt(level, template, args...)
+}
+func (l *logger) Tracet(template string, args ...interface{}) { l.logt(TraceLevel, template, args...) }
+func (l *logger) Debugt(template string, args ...interface{}) { l.logt(DebugLevel, template, args...) }
+func (l *logger) Infot(template string, args ...interface{})  { l.logt(InfoLevel, template, args...) }
+func (l *logger) Warnt(template string, args ...interface{})  { l.logt(WarnLevel, template, args...) }
+func (l *logger) Errort(template string, args ...interface{}) { l.logt(ErrorLevel, template, args...) }
+func (l *logger) Fatalt(template string, args ...interface{}) { l.logt(FatalLevel, template, args...) }
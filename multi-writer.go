@@ -0,0 +1,88 @@
+package log
+
+import (
+	"fmt"
+	"io"
+)
+
+//Sink is one output of NewMultiWriter()
+type Sink struct {
+	Writer io.Writer
+	//Encoder encodes the records for this sink,
+	//nil to write the record as encoded by the logger
+	Encoder IEncoder
+}
+
+//NewMultiWriter writes each record to all sinks, encoding it with the
+//encoder of each sink, so that e.g. the console gets everything while an
+//external shipper drops internal fields like request bodies and SQL text:
+//	log.Top().SetWriter(log.NewMultiWriter(
+//		log.Sink{Writer: os.Stderr, Encoder: log.DefaultEncoder(log.EncoderWithFields())},
+//		log.Sink{Writer: shipper, Encoder: log.FilterFields(log.NewJSONEncoder(), log.FieldFilter{Deny: []string{"sql", "*.body"}})},
+//	))
+//A failing sink does not stop the others, and the first error is returned.
+func NewMultiWriter(sinks ...Sink) io.WriteCloser {
+	return &multiWriter{sinks: sinks}
+}
+
+//multiWriter implements io.WriteCloser, IRecordWriter and IFlusher
+type multiWriter struct {
+	sinks []Sink
+}
+
+//Write is used for output without a record and writes p to all sinks
+func (mw *multiWriter) Write(p []byte) (int, error) {
+	var err error
+	for _, s := range mw.sinks {
+		if _, werr := s.Writer.Write(p); err == nil {
+			err = werr
+		}
+	}
+	return len(p), err
+}
+
+func (mw *multiWriter) WriteRecord(l ILogger, r Record, encoded []byte) (int, error) {
+	var err error
+	for _, s := range mw.sinks {
+		sinkEncoded := encoded
+		if s.Encoder != nil {
+			var eerr error
+			if sinkEncoded, eerr = encode(s.Encoder, l, r); eerr != nil {
+				if err == nil {
+					err = eerr
+				}
+				continue
+			}
+		}
+		if _, werr := timedWrite(s.Writer, l, r, sinkEncoded); werr != nil && err == nil {
+			err = fmt.Errorf("write to %s failed: %v", writerIdentity(s.Writer), werr)
+		}
+	}
+	return len(encoded), err
+} //multiWriter.WriteRecord()
+
+//Flush flushes the sinks that buffer output
+func (mw *multiWriter) Flush() error {
+	var err error
+	for _, s := range mw.sinks {
+		if f, ok := s.Writer.(IFlusher); ok {
+			if ferr := f.Flush(); err == nil {
+				err = ferr
+			}
+		}
+	}
+	return err
+}
+
+//Close closes the sinks that can be closed
+func (mw *multiWriter) Close() error {
+	var err error
+	for _, s := range mw.sinks {
+		if c, ok := s.Writer.(io.Closer); ok {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMultiWriterEncodesPerSink(t *testing.T) {
+	console, shipper := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	l := Top().Temp("multitest").WithWriter(NewMultiWriter(
+		Sink{Writer: console},
+		Sink{Writer: shipper, Encoder: FilterFields(NewJSONEncoder(), FieldFilter{Deny: []string{"sql"}})},
+	)).WithEncoder(DefaultEncoder(EncoderWithFields()))
+	l.With("sql", "select 1").With("user", "u1").Infof("query")
+
+	tests := []struct {
+		name    string
+		out     string
+		want    []string
+		notWant []string
+	}{
+		{"console", console.String(), []string{"sql=\"select 1\"", "user=u1"}, []string{"{"}},
+		{"shipper", shipper.String(), []string{`"user":"u1"`, `"message":"query"`}, []string{"select 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, s := range tt.want {
+				if !strings.Contains(tt.out, s) {
+					t.Errorf("missing %q in %q", s, tt.out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(tt.out, s) {
+					t.Errorf("unexpected %q in %q", s, tt.out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+//panicMonitorEnv is set in the environment of the monitored child process
+const panicMonitorEnv = "GO_MSVC_LOG_PANIC_MONITOR"
+
+//maxPanicOutput limits the runtime output kept for the fatal record
+const maxPanicOutput = 64 * 1024
+
+//CapturePanics makes runtime panics and fatal errors that are not
+//recovered, e.g. "panic: runtime error: index out of range" or
+//"fatal error: concurrent map writes", appear as a final FatalLevel record
+//in the configured writers rather than only as text on stderr. The runtime
+//writes these to stderr and exits without running deferred functions or
+//giving other goroutines a chance to log, so the process cannot capture
+//them itself: CapturePanics runs the program again as a child process with
+//the same arguments, copies its stderr through while watching for runtime
+//crash output, logs it with data "stack" and "exit_code" and exits with
+//the exit code of the child. Crash output is only logged when the child
+//exits with an error code (the runtime exits with 2), so that a recovered
+//panic written to stderr by the program is not logged as a crash.
+//
+//Everything main did before the call is done in both processes, so call it
+//first in main and pass the func that configures the log writers:
+//	func main() {
+//		log.CapturePanics(configureLogs)
+//		...
+//	}
+//The child calls configure and returns to run the program. The parent only
+//calls configure when the child crashed, so that its sinks (files, sockets,
+//shippers) are not opened twice. With a nil configure the parent logs the
+//crash to the writers configured before the call.
+//
+//If the child cannot be started, the error is reported (see
+//SetErrorHandler()) and it returns to run the program unmonitored.
+//Interrupt, SIGTERM, SIGHUP and SIGQUIT are forwarded to the child and the
+//parent waits for it to exit, which is why signal handling is not installed
+//by default (see HandleSignals()). Stdin, stdout, the sockets of systemd socket activation
+//(LISTEN_FDS) and the descriptor of ForwardFDEnv are passed to the child,
+//but not other inherited file descriptors.
+func CapturePanics(configure func()) {
+	if os.Getenv(panicMonitorEnv) != "" {
+		//sockets passed by systemd were for the parent
+		if os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getppid()) {
+			os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+		}
+		if configure != nil {
+			configure()
+		}
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		handleError(fmt.Errorf("cannot capture panics: %v", err))
+		return
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), panicMonitorEnv+"=1")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.ExtraFiles = inheritedFiles()
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		handleError(fmt.Errorf("cannot capture panics: %v", err))
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		handleError(fmt.Errorf("cannot capture panics: %v", err))
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP, syscall.SIGQUIT)
+	go func() {
+		for sig := range signals {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	crash := watchPanicOutput(stderr, os.Stderr)
+	err = cmd.Wait()
+	signal.Stop(signals)
+	code := 0
+	if err != nil {
+		code = 1
+		if cmd.ProcessState != nil && cmd.ProcessState.ExitCode() > 0 {
+			code = cmd.ProcessState.ExitCode()
+		}
+	}
+	if crash != "" && code != 0 {
+		if configure != nil {
+			configure()
+		}
+		msg, stack := crash, ""
+		if i := strings.Index(crash, "\n"); i >= 0 {
+			msg, stack = crash[:i], strings.TrimSpace(crash[i+1:])
+		}
+		Top().LogDepth(0, FatalLevel, msg,
+			Field{Name: "stack", Value: stack},
+			Field{Name: "exit_code", Value: code})
+	}
+	Shutdown()
+	//the files must not be closed by the garbage collector while the
+	//writers configured above may still write to the same descriptors
+	runtime.KeepAlive(cmd.ExtraFiles)
+	os.Exit(code)
+} //CapturePanics()
+
+//inheritedFiles returns the descriptors from 3 that the child must inherit,
+//with nil for the ones in between that are not passed
+func inheritedFiles() []*os.File {
+	fds := []int{}
+	if n, err := strconv.Atoi(os.Getenv("LISTEN_FDS")); err == nil {
+		for fd := 3; fd < 3+n; fd++ {
+			fds = append(fds, fd)
+		}
+	}
+	if fd, err := strconv.Atoi(os.Getenv(ForwardFDEnv)); err == nil && fd >= 3 {
+		fds = append(fds, fd)
+	}
+	var files []*os.File
+	for _, fd := range fds {
+		for len(files) <= fd-3 {
+			files = append(files, nil)
+		}
+		files[fd-3] = os.NewFile(uintptr(fd), "fd"+strconv.Itoa(fd))
+	}
+	return files
+} //inheritedFiles()
+
+//watchPanicOutput copies r to w until EOF and returns the runtime crash
+//output, i.e. everything from the last line starting with "panic: " or
+//"fatal error: ", or "" if there was none
+func watchPanicOutput(r io.Reader, w io.Writer) string {
+	crash := strings.Builder{}
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			w.Write([]byte(line))
+			if strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ") {
+				//the runtime writes the crash last
+				crash.Reset()
+				crash.WriteString(line)
+			} else if crash.Len() > 0 && crash.Len()+len(line) <= maxPanicOutput {
+				crash.WriteString(line)
+			}
+		}
+		if err != nil {
+			return strings.TrimRight(crash.String(), "\n")
+		}
+	}
+} //watchPanicOutput()
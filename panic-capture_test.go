@@ -0,0 +1,34 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWatchPanicOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		crash  string
+	}{
+		{"none", "starting\nstopped\n", ""},
+		{"panic", "starting\npanic: boom\n\ngoroutine 1 [running]:\nmain.main()\n", "panic: boom\n\ngoroutine 1 [running]:\nmain.main()"},
+		{"fatal error", "fatal error: concurrent map writes\n", "fatal error: concurrent map writes"},
+		{"recovered then crash", "panic: handled\nrecovered\npanic: boom\nmain.main()\n", "panic: boom\nmain.main()"},
+		{"nested", "panic: a [recovered]\n\tpanic: b\n", "panic: a [recovered]\n\tpanic: b"},
+		{"not at line start", "log: panic: boom\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copied := bytes.NewBuffer(nil)
+			crash := watchPanicOutput(strings.NewReader(tt.output), copied)
+			if crash != tt.crash {
+				t.Fatalf("crash = %q, want %q", crash, tt.crash)
+			}
+			if copied.String() != tt.output {
+				t.Fatalf("copied %q", copied.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+var panicOnError int32
+
+//SetPanicOnError makes the logger panic after writing an error record,
+//so that tests and local runs fail fast on conditions that are merely
+//logged in production. The panic value is an *ErrorRecordPanic.
+func SetPanicOnError(on bool) {
+	v := int32(0)
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&panicOnError, v)
+}
+
+//ErrorRecordPanic is the panic value when SetPanicOnError(true) and an
+//error record was logged
+type ErrorRecordPanic struct {
+	Logger string
+	Record Record
+}
+
+func (p *ErrorRecordPanic) Error() string {
+	return fmt.Sprintf("error logged at %s:%d: %s", p.Record.Caller.File, p.Record.Caller.Line, p.Record.Message)
+}
+
+//panicIfError panics after an error record was written in development mode
+func (l *logger) panicIfError(r Record) {
+	if (r.Level == ErrorLevel || r.Level == PanicLevel) && atomic.LoadInt32(&panicOnError) == 1 {
+		panic(&ErrorRecordPanic{Logger: l.Name(), Record: r})
+	}
+}
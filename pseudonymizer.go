@@ -0,0 +1,108 @@
+package log
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+//IPseudonymEscrow stores the identities replaced by pseudonyms so that
+//authorised users can look them up when required
+type IPseudonymEscrow interface {
+	Store(pseudonym, identity string)
+	Lookup(pseudonym string) (identity string, ok bool)
+}
+
+//NewMemoryEscrow returns an escrow that keeps identities in memory
+func NewMemoryEscrow() IPseudonymEscrow {
+	return &memoryEscrow{identities: map[string]string{}}
+}
+
+//memoryEscrow implements IPseudonymEscrow
+type memoryEscrow struct {
+	mutex      sync.RWMutex
+	identities map[string]string
+}
+
+func (e *memoryEscrow) Store(pseudonym, identity string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.identities[pseudonym] = identity
+}
+
+func (e *memoryEscrow) Lookup(pseudonym string) (string, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	identity, ok := e.identities[pseudonym]
+	return identity, ok
+}
+
+//Pseudonymize wraps an encoder to replace the values of identifier fields
+//(path.Match patterns e.g. "user_id", "*.email") with stable pseudonyms
+//derived from an HMAC of the value with key, so that logs can be analysed
+//per user without holding raw identities. The same value always gets the
+//same pseudonym for the same key. When escrow is not nil, each identity is
+//stored there so it can be looked up from its pseudonym.
+//The message of a record logged with a template (see ILogger.Logt()) is
+//written with the pseudonyms in the placeholders of identifier fields.
+//Identities in other messages are not replaced.
+func Pseudonymize(e IEncoder, key []byte, fields []string, escrow IPseudonymEscrow) IEncoder {
+	return pseudonymizer{
+		encoder: e,
+		key:     append([]byte{}, key...),
+		fields:  fields,
+		escrow:  escrow,
+	}
+}
+
+type pseudonymizer struct {
+	encoder IEncoder
+	key     []byte
+	fields  []string
+	escrow  IPseudonymEscrow
+}
+
+func (p pseudonymizer) Encode(l ILogger, r Record) []byte {
+	r.Message = p.message(r)
+	return p.encoder.Encode(l, r.withStage(func(data map[string]interface{}) {
+		for n, v := range data {
+			if v != nil && matchAny(p.fields, n) {
+				data[n] = p.pseudonym(fmt.Sprint(v))
+			}
+		}
+	}))
+}
+
+//message returns the message of a record logged with a template (see
+//ILogger.Logt()) with pseudonyms in the placeholders of identifier fields
+func (p pseudonymizer) message(r Record) string {
+	template, prefix, ok := recordTemplate(r)
+	if !ok {
+		return r.Message
+	}
+	values := map[string]interface{}{}
+	for _, f := range r.Fields {
+		values[f.Name] = f.Value
+	}
+	mt := parseMessageTemplate(template)
+	return cleanMessage(mt.message(func(i int) (string, bool) {
+		n := prefix + templateFieldName(i, mt.names[i])
+		v, ok := values[n]
+		if !ok {
+			return "", false
+		}
+		if v != nil && matchAny(p.fields, n) {
+			return p.pseudonym(fmt.Sprint(v)), true
+		}
+		return fmt.Sprint(v), true
+	}))
+} //pseudonymizer.message()
+
+//pseudonym returns "pn_" followed by the first 16 hex digits of the HMAC
+func (p pseudonymizer) pseudonym(identity string) string {
+	pseudonym := "pn_" + hex.EncodeToString(hmacSum(p.key, []byte(identity))[:8])
+	if p.escrow != nil {
+		p.escrow.Store(pseudonym, identity)
+	}
+	return pseudonym
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPseudonymizeTemplateMessage(t *testing.T) {
+	escrow := NewMemoryEscrow()
+	buf := bytes.NewBuffer(nil)
+	e := Pseudonymize(NewJSONEncoder(), []byte("key"), []string{"user_id", "*.email"}, escrow)
+	l := Top().Temp("pseudonymtest").WithWriter(buf).WithEncoder(e)
+
+	tests := []struct {
+		name    string
+		log     func()
+		want    []string
+		notWant []string
+	}{
+		{"placeholder", func() { l.Infot("user {user_id} bought {count} items", "u-123", 3) },
+			[]string{`"count":3`, " bought 3 items"}, []string{"u-123"}},
+		{"group", func() { l.WithGroup("req").Infot("mail to {email}", "a@b.c") },
+			[]string{"mail to pn_"}, []string{"a@b.c"}},
+		{"not an identifier", func() { l.Infot("order {order_id}", "o-1") },
+			[]string{`"message":"order o-1"`}, nil},
+		{"missing arg", func() { l.Infot("user {user_id}") },
+			[]string{`"message":"user {user_id}"`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			tt.log()
+			for _, s := range tt.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("missing %q in %s", s, buf)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(buf.String(), s) {
+					t.Errorf("identity %q in %s", s, buf)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"io"
 	"time"
 )
 
@@ -10,6 +11,75 @@ type Record struct {
 	Caller  Caller
 	Level   Level
 	Message string
+	//Event is the name of the event for records written by ILogger.Event(),
+	//which is also the message, or "" for other records
+	Event  string
+	Fields []Field
+	//Data is a snapshot of the logger data (own and inherited) taken when
+	//the record was logged, so that encoders and async writers see the
+	//data as it was, even when it is changed by other goroutines later
+	//it is shared by all writers and must not be modified
+	Data map[string]interface{}
+
+	//stages are set by encoder wrappers like FilterFields to change the
+	//data written by the encoder, applied in order by recordData()
+	stages []func(data map[string]interface{})
+}
+
+//withStage returns a copy of the record with a data stage added
+func (r Record) withStage(stage func(data map[string]interface{})) Record {
+	r.stages = append(r.stages[:len(r.stages):len(r.stages)], stage)
+	return r
+}
+
+//Field is a name-value logged with one record in addition to the logger data
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+//recordData returns the data of the record with the record fields added
+//as a new map that may be modified
+func recordData(l ILogger, r Record) map[string]interface{} {
+	var data map[string]interface{}
+	if r.Data != nil {
+		data = make(map[string]interface{}, len(r.Data)+len(r.Fields))
+		for n, v := range r.Data {
+			data[n] = v
+		}
+	} else {
+		//record not made by a logger
+		data = l.Data()
+	}
+	for _, f := range r.Fields {
+		data[f.Name] = f.Value
+	}
+	for _, stage := range r.stages {
+		stage(data)
+	}
+	return data
+}
+
+//RecordData returns the data of the record as the encoders write it: the
+//logger data with the record fields added and the changes of encoder
+//wrappers like FilterFields() applied, as a new map that may be modified.
+//It is for encoders in other packages.
+func RecordData(l ILogger, r Record) map[string]interface{} {
+	return recordData(l, r)
+}
+
+//IRecordWriter is implemented by writers that need the record and not
+//only the encoded bytes, e.g. to route records by a data value
+//the logger calls WriteRecord instead of Write on such writers
+type IRecordWriter interface {
+	WriteRecord(l ILogger, r Record, encoded []byte) (int, error)
+}
+
+func writeRecord(w io.Writer, l ILogger, r Record, encoded []byte) (int, error) {
+	if rw, ok := w.(IRecordWriter); ok {
+		return rw.WriteRecord(l, r, encoded)
+	}
+	return w.Write(encoded)
 }
 
 //IEncoder ...
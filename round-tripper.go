@@ -0,0 +1,71 @@
+package log
+
+import (
+	"net/http"
+	"time"
+)
+
+//NewRoundTripper wraps an HTTP transport (http.DefaultTransport when next
+//is nil) to propagate the trace context of the request context (see
+//TraceContextFromContext()) in the headers of outbound requests as a new
+//child span of the current span, by default in W3C and B3 multi header
+//format, and to log each request at DebugLevel, or WarnLevel when it fails, with data "method", "url", "status" and
+//"duration_ms". The logger of the request context (see FromContext()) is
+//used when set, else l. Use it as the transport of an http.Client:
+//	client := &http.Client{Transport: log.NewRoundTripper(log.Logger("client"), nil)}
+func NewRoundTripper(l ILogger, next http.RoundTripper, formats ...Propagation) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	p := PropagateW3C | PropagateB3Multi
+	if len(formats) > 0 {
+		p = 0
+		for _, f := range formats {
+			p |= f
+		}
+	}
+	return roundTripper{logger: l, next: next, propagation: p}
+}
+
+type roundTripper struct {
+	logger      ILogger
+	next        http.RoundTripper
+	propagation Propagation
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if tc, ok := TraceContextFromContext(ctx); ok {
+		//the request must not be modified, so change a copy
+		req = req.WithContext(ctx)
+		req.Header = cloneHeader(req.Header)
+		//the request is a new client span that is a child of the current
+		//span, so that the downstream server span becomes its grandchild
+		tc.Child().Inject(req.Header, rt.propagation)
+	}
+	l := FromContext(ctx, rt.logger)
+	start := time.Now()
+	res, err := rt.next.RoundTrip(req)
+	//do not log passwords in the URL
+	u := *req.URL
+	u.User = nil
+	fields := []Field{
+		{Name: "method", Value: req.Method},
+		{Name: "url", Value: u.String()},
+		{Name: "duration_ms", Value: since(start).Nanoseconds() / int64(time.Millisecond)},
+	}
+	if err != nil {
+		l.LogDepth(0, WarnLevel, "http request failed", append(fields, Field{Name: "error", Value: err})...)
+		return res, err
+	}
+	l.LogDepth(0, DebugLevel, "http request", append(fields, Field{Name: "status", Value: res.StatusCode})...)
+	return res, nil
+} //roundTripper.RoundTrip()
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
@@ -0,0 +1,37 @@
+package log
+
+import "sync/atomic"
+
+func (l *logger) SetSampling(level Level, n int) {
+	if level < _minLevel || level > _maxLevel {
+		return
+	}
+	if n < 1 {
+		n = 1
+	}
+	l.sampling[level-_minLevel] = n
+	for _, ll := range l.subs {
+		ll.WithSampling(level, n)
+	}
+} //logger.SetSampling()
+
+func (l *logger) WithSampling(level Level, n int) ILogger {
+	l.SetSampling(level, n)
+	return l
+}
+
+//sample is true if the record must be kept, which is the first
+//and then every n'th record at the level
+//records of unnamed temp loggers (e.g. groups) count in their named parent,
+//so that a temp logger per request is sampled like the parent
+func (l *logger) sample(level Level) bool {
+	if level < _minLevel || level > _maxLevel {
+		return true
+	}
+	n := l.sampling[level-_minLevel]
+	if n <= 1 {
+		return true
+	}
+	count := atomic.AddUint64(&l.statsNode().sampled[level-_minLevel], 1)
+	return (count-1)%uint64(n) == 0
+} //logger.sample()
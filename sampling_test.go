@@ -0,0 +1,31 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSamplingCountsInNamedLogger(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	l := Top().Logger("samplingtest").WithWriter(buf).WithSampling(InfoLevel, 5)
+	tests := []struct {
+		name string
+		log  func(i int)
+	}{
+		{"logger", func(i int) { l.Infof("record %d", i) }},
+		{"group per record", func(i int) { l.WithGroup("req").Infof("record %d", i) }},
+		{"with per record", func(i int) { l.With("i", i).Infof("record %d", i) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			for i := 0; i < 20; i++ {
+				tt.log(i)
+			}
+			if n := strings.Count(buf.String(), "record"); n != 4 {
+				t.Fatalf("kept %d of 20 records, want 4:\n%s", n, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+//FieldType is the JSON type allowed for a field in a Schema
+type FieldType int
+
+const (
+	//AnyType allows any value
+	AnyType FieldType = iota
+	//StringType allows strings, errors and fmt.Stringers
+	StringType
+	//NumberType allows ints, uints and floats
+	NumberType
+	//BoolType allows bool
+	BoolType
+	//TimeType allows time.Time
+	TimeType
+	//ObjectType allows structs and maps
+	ObjectType
+	//ArrayType allows slices and arrays
+	ArrayType
+)
+
+func (t FieldType) String() string {
+	switch t {
+	case AnyType:
+		return "any"
+	case StringType:
+		return "string"
+	case NumberType:
+		return "number"
+	case BoolType:
+		return "bool"
+	case TimeType:
+		return "time"
+	case ObjectType:
+		return "object"
+	case ArrayType:
+		return "array"
+	}
+	return fmt.Sprintf("FieldType(%d)", int(t))
+}
+
+//SchemaAction is what happens to a record that violates the schema
+type SchemaAction int
+
+const (
+	//SchemaAnnotate logs the record with field "schema_violation" describing the problem
+	SchemaAnnotate SchemaAction = iota
+	//SchemaQuarantine writes the record to the schema quarantine writer instead of the logger writer
+	SchemaQuarantine
+	//SchemaReject panics, to find violations during development and tests
+	SchemaReject
+)
+
+//Schema describes the data (logger data and record fields) that records must have
+//so that ingestion does not break when e.g. a field changes from string to number
+type Schema struct {
+	//Required names must be in all records
+	Required []string
+	//Types of data values, names not listed may have any type
+	Types map[string]FieldType
+	//Action for records that violate the schema
+	Action SchemaAction
+	//Quarantine is written for SchemaQuarantine and is required for it,
+	//records are annotated and written normally when it is nil
+	Quarantine io.Writer
+}
+
+//Check returns a description of the violations or "" if the data is valid
+func (s *Schema) Check(data map[string]interface{}) string {
+	violations := []string{}
+	for _, n := range s.Required {
+		if _, ok := data[n]; !ok {
+			violations = append(violations, "missing "+n)
+		}
+	}
+	names := make([]string, 0, len(s.Types))
+	for n := range s.Types {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		v, ok := data[n]
+		if !ok {
+			continue
+		}
+		if t := s.Types[n]; !isFieldType(v, t) {
+			violations = append(violations, fmt.Sprintf("%s is %T not %s", n, v, t))
+		}
+	}
+	return strings.Join(violations, ", ")
+} //Schema.Check()
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+func isFieldType(v interface{}, t FieldType) bool {
+	if t == AnyType {
+		return true
+	}
+	if v == nil {
+		return false
+	}
+	if _, ok := v.(time.Time); ok {
+		return t == TimeType
+	}
+	rv := reflect.ValueOf(v)
+	if t == StringType && (rv.Type().Implements(errorType) || rv.Type().Implements(stringerType)) {
+		return true
+	}
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return t == StringType
+	case reflect.Bool:
+		return t == BoolType
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return t == NumberType
+	case reflect.Struct, reflect.Map:
+		return t == ObjectType
+	case reflect.Slice, reflect.Array:
+		return t == ArrayType
+	}
+	return false
+} //isFieldType()
+
+func (l *logger) SetSchema(s *Schema) {
+	if s != nil && s.Action == SchemaQuarantine && s.Quarantine == nil {
+		panic("schema quarantine writer is nil")
+	}
+	l.schema = s
+	for _, ll := range l.subs {
+		ll.WithSchema(s)
+	}
+}
+
+func (l *logger) WithSchema(s *Schema) ILogger {
+	l.SetSchema(s)
+	return l
+}
+
+//checkSchema applies the schema action to a record that violates the
+//schema and returns the writer for the record, or nil if the record
+//must not be written
+func (l *logger) checkSchema(r *Record) io.Writer {
+	writer := l.Writer()
+	if l.schema == nil {
+		return writer
+	}
+	violation := l.schema.Check(recordData(l, *r))
+	if violation == "" {
+		return writer
+	}
+	switch l.schema.Action {
+	case SchemaQuarantine:
+		//Quarantine may have been cleared after the schema was set
+		if l.schema.Quarantine != nil {
+			return l.schema.Quarantine
+		}
+	case SchemaReject:
+		panic(fmt.Sprintf("log record %q from %s:%d violates schema: %s", r.Message, r.Caller.File, r.Caller.Line, violation))
+	}
+	r.Fields = append(append([]Field{}, r.Fields...), Field{Name: "schema_violation", Value: violation})
+	return writer
+} //logger.checkSchema()
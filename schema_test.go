@@ -0,0 +1,32 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSchemaQuarantine(t *testing.T) {
+	quarantine := &bytes.Buffer{}
+	buf := &bytes.Buffer{}
+	schema := &Schema{Required: []string{"id"}, Action: SchemaQuarantine, Quarantine: quarantine}
+	l := Top().Temp("xtest").WithWriter(buf).WithEncoder(NewJSONEncoder()).WithSchema(schema)
+	l.Infof("bad")
+	if quarantine.Len() == 0 || buf.Len() != 0 {
+		t.Fatalf("quarantined %q, written %q", quarantine, buf)
+	}
+
+	//a cleared quarantine writer does not drop records
+	schema.Quarantine = nil
+	l.Infof("bad")
+	if !strings.Contains(buf.String(), "missing id") {
+		t.Fatalf("written %q, want the record with the violation", buf)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("schema without quarantine writer was accepted")
+		}
+	}()
+	l.SetSchema(&Schema{Action: SchemaQuarantine})
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"regexp"
+	"sync/atomic"
+)
+
+//SecretMask replaces secrets found by ScanSecrets
+const SecretMask = "[REDACTED]"
+
+//DefaultSecretPatterns are used by ScanSecrets when no patterns are specified
+//When a pattern has a sub-match, that part is kept, e.g. "Bearer " in "Bearer abc.def"
+var DefaultSecretPatterns = []*regexp.Regexp{
+	//AWS access key ids
+	regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`),
+	//JWTs
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	//private keys in PEM format, also when newlines were escaped by the encoder
+	regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY-----[\s\S]*?-----END[A-Z ]*PRIVATE KEY-----`),
+	//bearer tokens
+	regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9\-._~+/]+=*`),
+}
+
+//ScanSecrets wraps an encoder to mask secrets in the encoded records, as a
+//safety net for secrets that slip into messages or data values by accident
+//Each secret masked is counted as "secrets_redacted" in the logger stats
+func ScanSecrets(e IEncoder, patterns ...*regexp.Regexp) IEncoder {
+	if len(patterns) == 0 {
+		patterns = DefaultSecretPatterns
+	}
+	return secretScanner{encoder: e, patterns: patterns}
+}
+
+type secretScanner struct {
+	encoder  IEncoder
+	patterns []*regexp.Regexp
+}
+
+func (s secretScanner) Encode(l ILogger, r Record) []byte {
+	encoded := s.encoder.Encode(l, r)
+	redacted := 0
+	for _, p := range s.patterns {
+		if n := len(p.FindAllIndex(encoded, -1)); n > 0 {
+			redacted += n
+			encoded = p.ReplaceAll(encoded, []byte("${1}"+SecretMask))
+		}
+	}
+	if redacted > 0 {
+		if ll, ok := l.(*logger); ok {
+			atomic.AddUint64(&ll.statsNode().secretsRedacted, uint64(redacted))
+		}
+	}
+	return encoded
+} //secretScanner.Encode()
+
+//redactSecrets masks secrets in a message that is passed on without the
+//encoder, e.g. in stats and alerts, using the patterns of ScanSecrets when
+//e is a secret scanner, else DefaultSecretPatterns
+func redactSecrets(e IEncoder, msg string) string {
+	patterns := DefaultSecretPatterns
+	if s, ok := e.(secretScanner); ok {
+		patterns = s.patterns
+	}
+	for _, p := range patterns {
+		msg = p.ReplaceAllString(msg, "${1}"+SecretMask)
+	}
+	return msg
+} //redactSecrets()
@@ -0,0 +1,282 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//segment files consist of frames, each with a header of 4 bytes record
+//length and 4 bytes CRC-32C of the record (both big endian) followed by
+//the record
+const (
+	segmentHeaderSize = 8
+	segmentExt        = ".seg"
+)
+
+var segmentTable = crc32.MakeTable(crc32.Castagnoli)
+
+//ISegmentWriter is a durable local sink, see NewSegmentWriter()
+type ISegmentWriter interface {
+	io.WriteCloser
+	IFlusher
+	//Segments returns the paths of all segment files in write order,
+	//the last one being the segment currently written
+	Segments() ([]string, error)
+}
+
+//NewSegmentWriter writes each record as a frame with a checksum to append-only
+//segment files in dir, starting a new file when the current one would exceed
+//segmentSize bytes. Existing segments are recovered first (see RecoverSegments)
+//so that a torn write from a crash does not corrupt the logs.
+//Flush() syncs the current segment to disk.
+func NewSegmentWriter(dir string, segmentSize int64) (ISegmentWriter, error) {
+	if segmentSize <= segmentHeaderSize {
+		return nil, fmt.Errorf("segment size %d too small", segmentSize)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create segment dir: %v", err)
+	}
+	if _, err := RecoverSegments(dir); err != nil {
+		return nil, err
+	}
+	sw := &segmentWriter{dir: dir, segmentSize: segmentSize}
+	paths, err := sw.Segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) > 0 {
+		last := paths[len(paths)-1]
+		sw.seq, _ = segmentSeq(last)
+		if sw.file, err = os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			return nil, fmt.Errorf("cannot open segment: %v", err)
+		}
+		info, err := sw.file.Stat()
+		if err != nil {
+			sw.file.Close()
+			return nil, fmt.Errorf("cannot stat segment: %v", err)
+		}
+		sw.size = info.Size()
+	}
+	return sw, nil
+} //NewSegmentWriter()
+
+//segmentWriter implements ISegmentWriter
+type segmentWriter struct {
+	mutex       sync.Mutex
+	dir         string
+	segmentSize int64
+	seq         uint64
+	file        *os.File
+	size        int64
+}
+
+func (sw *segmentWriter) Write(p []byte) (int, error) {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	frameSize := int64(segmentHeaderSize + len(p))
+	if sw.file == nil || (sw.size > 0 && sw.size+frameSize > sw.segmentSize) {
+		if err := sw.next(); err != nil {
+			return 0, err
+		}
+	}
+	frame := make([]byte, frameSize)
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(p)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.Checksum(p, segmentTable))
+	copy(frame[segmentHeaderSize:], p)
+	if n, err := sw.file.Write(frame); err != nil {
+		//remove a partial frame, or roll to a new segment when that fails,
+		//so that later frames are not written after a torn one
+		if n > 0 && sw.file.Truncate(sw.size) != nil {
+			sw.file.Close()
+			sw.file = nil
+		}
+		return 0, err
+	}
+	sw.size += frameSize
+	return len(p), nil
+} //segmentWriter.Write()
+
+//next closes the current segment and starts a new one
+func (sw *segmentWriter) next() error {
+	if sw.file != nil {
+		if err := sw.file.Sync(); err != nil {
+			return err
+		}
+		if err := sw.file.Close(); err != nil {
+			return err
+		}
+		sw.file = nil
+	}
+	sw.seq++
+	f, err := os.OpenFile(sw.segmentPath(sw.seq), os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot create segment: %v", err)
+	}
+	sw.file = f
+	sw.size = 0
+	return nil
+}
+
+func (sw *segmentWriter) Flush() error {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	if sw.file == nil {
+		return nil
+	}
+	return sw.file.Sync()
+}
+
+func (sw *segmentWriter) Close() error {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	if sw.file == nil {
+		return nil
+	}
+	err := sw.file.Sync()
+	if cerr := sw.file.Close(); err == nil {
+		err = cerr
+	}
+	sw.file = nil
+	return err
+}
+
+func (sw *segmentWriter) Segments() ([]string, error) {
+	return segmentPaths(sw.dir)
+}
+
+func (sw *segmentWriter) segmentPath(seq uint64) string {
+	return filepath.Join(sw.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+//segmentPaths returns the segment files in dir sorted by sequence
+func segmentPaths(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read segment dir: %v", err)
+	}
+	paths := []string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if _, ok := segmentSeq(e.Name()); ok {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func segmentSeq(path string) (uint64, bool) {
+	name := filepath.Base(path)
+	if !strings.HasSuffix(name, segmentExt) {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+	return seq, err == nil
+}
+
+//ReadSegment calls fn for each record in the segment file, in write order.
+//It fails on the first torn or corrupt frame, so that a segment can be
+//verified before it is shipped.
+func ReadSegment(path string, fn func(record []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	_, err = readFrames(bufio.NewReader(f), info.Size(), fn)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+//VerifySegment returns an error if any record in the segment file is corrupt
+func VerifySegment(path string) error {
+	return ReadSegment(path, func([]byte) error { return nil })
+}
+
+//RecoverSegments truncates each segment in dir after its last valid frame,
+//removing torn writes left by a crash, and returns the nr of bytes removed
+func RecoverSegments(dir string) (int64, error) {
+	paths, err := segmentPaths(dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := int64(0)
+	for _, path := range paths {
+		n, err := recoverSegment(path)
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+func recoverSegment(path string) (int64, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	valid, _ := readFrames(bufio.NewReader(f), info.Size(), func([]byte) error { return nil })
+	if valid == info.Size() {
+		return 0, nil
+	}
+	if err := f.Truncate(valid); err != nil {
+		return 0, fmt.Errorf("cannot truncate %s: %v", path, err)
+	}
+	return info.Size() - valid, f.Sync()
+} //recoverSegment()
+
+//readFrames calls fn for each valid frame in the size bytes read from r and
+//returns the nr of bytes of valid frames and an error when a frame is torn or
+//corrupt. The record length in a header is checked against the remaining
+//size before the record is allocated.
+func readFrames(r io.Reader, size int64, fn func([]byte) error) (int64, error) {
+	valid := int64(0)
+	header := make([]byte, segmentHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return valid, nil
+			}
+			return valid, fmt.Errorf("torn frame header at offset %d", valid)
+		}
+		length := int64(binary.BigEndian.Uint32(header[0:4]))
+		if length > size-valid-segmentHeaderSize {
+			return valid, fmt.Errorf("torn frame at offset %d", valid)
+		}
+		record := make([]byte, length)
+		if _, err := io.ReadFull(r, record); err != nil {
+			return valid, fmt.Errorf("torn frame at offset %d", valid)
+		}
+		if crc32.Checksum(record, segmentTable) != binary.BigEndian.Uint32(header[4:8]) {
+			return valid, fmt.Errorf("checksum mismatch at offset %d", valid)
+		}
+		if err := fn(record); err != nil {
+			return valid, err
+		}
+		valid += int64(segmentHeaderSize + len(record))
+	}
+} //readFrames()
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSegmentWriterRecovery(t *testing.T) {
+	header := func(length uint32) []byte {
+		h := make([]byte, segmentHeaderSize)
+		binary.BigEndian.PutUint32(h[0:4], length)
+		return h
+	}
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{"clean", nil},
+		{"torn header", []byte{0, 0, 0}},
+		{"torn record", append(header(10), "abc"...)},
+		{"huge length", header(0xffffffff)},
+		{"bad checksum", append(header(3), "abc"...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "segments")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			sw, err := NewSegmentWriter(dir, 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range []string{"one", "two"} {
+				if _, err := sw.Write([]byte(r)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			sw.Close()
+			path := filepath.Join(dir, "00000000000000000001"+segmentExt)
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Write(tt.tail)
+			f.Close()
+			if err := VerifySegment(path); (err != nil) != (len(tt.tail) > 0) {
+				t.Fatalf("VerifySegment() = %v", err)
+			}
+
+			//reopening recovers the segment and appends after the last valid frame
+			sw, err = NewSegmentWriter(dir, 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sw.Write([]byte("three"))
+			sw.Close()
+			records := []string{}
+			if err := ReadSegment(path, func(r []byte) error {
+				records = append(records, string(r))
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(records, []string{"one", "two", "three"}) {
+				t.Fatalf("records = %q", records)
+			}
+		})
+	}
+}
+
+func TestSegmentWriterRollsOver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "segments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sw, err := NewSegmentWriter(dir, 2*(segmentHeaderSize+5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		sw.Write([]byte("hello"))
+	}
+	sw.Close()
+	paths, _ := segmentPaths(dir)
+	if len(paths) != 3 {
+		t.Fatalf("segments = %q", paths)
+	}
+}
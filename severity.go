@@ -0,0 +1,113 @@
+package log
+
+//Severity is the equivalent of a level in an external system
+//Code is the numeric value (if the system uses one) and Name the text
+type Severity struct {
+	Code int
+	Name string
+}
+
+//ISeverityMap maps levels to the severities of an external system, so that
+//sinks can consult a map rather than each implementing its own switch
+//Use With() to override the severity of a level, e.g.
+//	SyslogSeverities().With(WarnLevel, Severity{Code: 5, Name: "notice"})
+type ISeverityMap interface {
+	//Name of the external system
+	Name() string
+	//Severity of the level, or the default severity for an unknown level
+	Severity(level Level) Severity
+	//With returns a copy of the map with the severity for level overridden
+	With(level Level, s Severity) ISeverityMap
+}
+
+//NewSeverityMap creates a map for any system not provided below
+//def is used for levels not in the map
+func NewSeverityMap(name string, def Severity, severities map[Level]Severity) ISeverityMap {
+	sm := severityMap{
+		name:       name,
+		def:        def,
+		severities: map[Level]Severity{},
+	}
+	for l, s := range severities {
+		sm.severities[l] = s
+	}
+	return sm
+}
+
+//SyslogSeverities maps levels to RFC 5424 syslog severities
+func SyslogSeverities() ISeverityMap {
+	return NewSeverityMap("syslog", Severity{Code: 5, Name: "notice"}, map[Level]Severity{
+		TraceLevel: {Code: 7, Name: "debug"},
+		DebugLevel: {Code: 7, Name: "debug"},
+		InfoLevel:  {Code: 6, Name: "info"},
+		WarnLevel:  {Code: 4, Name: "warning"},
+		ErrorLevel: {Code: 3, Name: "err"},
+		PanicLevel: {Code: 2, Name: "crit"},
+		FatalLevel: {Code: 1, Name: "alert"},
+	})
+}
+
+//GCPSeverities maps levels to Google Cloud Logging LogSeverity
+func GCPSeverities() ISeverityMap {
+	return NewSeverityMap("gcp", Severity{Code: 0, Name: "DEFAULT"}, map[Level]Severity{
+		TraceLevel: {Code: 100, Name: "DEBUG"},
+		DebugLevel: {Code: 100, Name: "DEBUG"},
+		InfoLevel:  {Code: 200, Name: "INFO"},
+		WarnLevel:  {Code: 400, Name: "WARNING"},
+		ErrorLevel: {Code: 500, Name: "ERROR"},
+		PanicLevel: {Code: 600, Name: "CRITICAL"},
+		FatalLevel: {Code: 700, Name: "ALERT"},
+	})
+}
+
+//WindowsEventTypes maps levels to Windows event log types
+func WindowsEventTypes() ISeverityMap {
+	return NewSeverityMap("windows", Severity{Code: 4, Name: "Information"}, map[Level]Severity{
+		TraceLevel: {Code: 4, Name: "Information"},
+		DebugLevel: {Code: 4, Name: "Information"},
+		InfoLevel:  {Code: 4, Name: "Information"},
+		WarnLevel:  {Code: 2, Name: "Warning"},
+		ErrorLevel: {Code: 1, Name: "Error"},
+		PanicLevel: {Code: 1, Name: "Error"},
+		FatalLevel: {Code: 1, Name: "Error"},
+	})
+}
+
+//PagerDutyUrgencies maps levels to PagerDuty incident urgencies
+func PagerDutyUrgencies() ISeverityMap {
+	return NewSeverityMap("pagerduty", Severity{Code: 0, Name: "low"}, map[Level]Severity{
+		TraceLevel: {Code: 0, Name: "low"},
+		DebugLevel: {Code: 0, Name: "low"},
+		InfoLevel:  {Code: 0, Name: "low"},
+		WarnLevel:  {Code: 0, Name: "low"},
+		ErrorLevel: {Code: 1, Name: "high"},
+		PanicLevel: {Code: 1, Name: "high"},
+		FatalLevel: {Code: 1, Name: "high"},
+	})
+}
+
+//severityMap implements ISeverityMap
+type severityMap struct {
+	name       string
+	def        Severity
+	severities map[Level]Severity
+}
+
+func (sm severityMap) Name() string { return sm.name }
+
+func (sm severityMap) Severity(level Level) Severity {
+	if s, ok := sm.severities[level]; ok {
+		return s
+	}
+	return sm.def
+}
+
+func (sm severityMap) With(level Level, s Severity) ISeverityMap {
+	severities := map[Level]Severity{}
+	for l, ls := range sm.severities {
+		severities[l] = ls
+	}
+	severities[level] = s
+	sm.severities = severities
+	return sm
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	shutdownMutex sync.Mutex
+	shutdownHooks []func()
+	signalsOnce   sync.Once
+)
+
+//OnShutdown registers fn to run when the process terminates through
+//Shutdown(), Must() or a SIGTERM/SIGINT after HandleSignals(), e.g. to
+//stop an async sink after writing its queued records. Hooks run in
+//reverse order of registration before the writers of all loggers are flushed.
+func OnShutdown(fn func()) {
+	shutdownMutex.Lock()
+	shutdownHooks = append(shutdownHooks, fn)
+	shutdownMutex.Unlock()
+}
+
+//Shutdown runs the shutdown hooks once and flushes all loggers, call it
+//(e.g. deferred in main) before exiting so the final records are written
+func Shutdown() {
+	shutdownMutex.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	shutdownMutex.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+	Flush()
+}
+
+//HandleSignals installs a handler for SIGTERM and SIGINT that calls
+//Shutdown() and then raises the signal again with the handler removed,
+//so that buffered records are written before the process terminates as
+//the sender expects. It is for programs that do not handle these signals
+//themselves: a program that does should call Shutdown() at the end of its
+//own graceful shutdown instead, because the raised signal is also delivered
+//to its handler. Calling it more than once has no effect.
+//Where the signal cannot be raised, e.g. on Windows, the process exits
+//with the conventional code 128+signal, i.e. 130 for SIGINT and 143 for
+//SIGTERM.
+//
+//The handler is not installed automatically: writers and OnShutdown()
+//used to install it when created, which changed how programs that handle
+//signals themselves terminate. Programs that relied on that must now call
+//HandleSignals() in main.
+func HandleSignals() {
+	signalsOnce.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+		go func() {
+			sig := <-signals
+			Shutdown()
+			//only remove this handler, not those of the program
+			signal.Stop(signals)
+			p, err := os.FindProcess(os.Getpid())
+			if err == nil {
+				err = p.Signal(sig)
+			}
+			if err != nil {
+				os.Exit(signalExitCode(sig))
+			}
+		}()
+	})
+} //HandleSignals()
+
+//signalExitCode is the exit code of a shell for a process terminated by sig
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
@@ -0,0 +1,24 @@
+package log
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestSignalExitCode(t *testing.T) {
+	tests := []struct {
+		sig  os.Signal
+		want int
+	}{
+		{os.Interrupt, 130},
+		{syscall.SIGTERM, 143},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sig.String(), func(t *testing.T) {
+			if got := signalExitCode(tt.sig); got != tt.want {
+				t.Fatalf("exit code %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package log
+
+import (
+	"expvar"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//LatencyBounds are the upper bounds of the write latency histogram buckets
+//in WriterStats.Latency, the last bucket counts slower writes
+var LatencyBounds = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+//WriterStats describes the performance of one writer, to tell whether
+//logging or the collector is the bottleneck
+type WriterStats struct {
+	//Writes is the nr of records written and Errors the nr that failed
+	Writes uint64 `json:"writes"`
+	Errors uint64 `json:"errors"`
+	Bytes  uint64 `json:"bytes"`
+	//Latency counts writes per LatencyBounds bucket
+	Latency []uint64 `json:"latency"`
+	//Batches and BatchBytes are the nr and total size of batches sent by
+	//batching writers, e.g. the HTTP writer
+	Batches    uint64 `json:"batches,omitempty"`
+	BatchBytes uint64 `json:"batch_bytes,omitempty"`
+	//Retries counts sends that were tried again, e.g. after reconnecting
+	Retries uint64 `json:"retries,omitempty"`
+	//Drops counts writes that were dropped, e.g. by a deadline writer
+	Drops uint64 `json:"drops,omitempty"`
+}
+
+//writerStats is updated atomically
+type writerStats struct {
+	writes     uint64
+	errors     uint64
+	bytes      uint64
+	latency    []uint64
+	batches    uint64
+	batchBytes uint64
+	retries    uint64
+	drops      uint64
+	used       int64 //unix nanoseconds of the last update
+}
+
+//maxWriterStats limits the nr of writers with stats, so that writers that
+//are no longer used, e.g. of temporary loggers, are not kept forever
+var maxWriterStats = 256
+
+var (
+	writerStatsMutex sync.RWMutex
+	writerStatsByKey = map[io.Writer]*writerStats{}
+	writerStatsNames = map[io.Writer]string{}
+)
+
+//SinkStats returns the stats of all writers used by loggers and of the
+//built-in writers inside them, indexed by writer identity as shown by DumpConfig.
+//Stats are kept for the 256 most recently used writers only.
+func SinkStats() map[string]WriterStats {
+	writerStatsMutex.RLock()
+	defer writerStatsMutex.RUnlock()
+	stats := map[string]WriterStats{}
+	for key, ws := range writerStatsByKey {
+		s := WriterStats{
+			Writes:     atomic.LoadUint64(&ws.writes),
+			Errors:     atomic.LoadUint64(&ws.errors),
+			Bytes:      atomic.LoadUint64(&ws.bytes),
+			Latency:    make([]uint64, len(ws.latency)),
+			Batches:    atomic.LoadUint64(&ws.batches),
+			BatchBytes: atomic.LoadUint64(&ws.batchBytes),
+			Retries:    atomic.LoadUint64(&ws.retries),
+			Drops:      atomic.LoadUint64(&ws.drops),
+		}
+		for i := range ws.latency {
+			s.Latency[i] = atomic.LoadUint64(&ws.latency[i])
+		}
+		stats[writerStatsNames[key]] = s
+	}
+	return stats
+} //SinkStats()
+
+//statsOf returns the stats of writer w, created when first used,
+//or nil when w cannot be a map key
+func statsOf(w io.Writer) *writerStats {
+	if !reflect.TypeOf(w).Comparable() {
+		return nil
+	}
+	writerStatsMutex.RLock()
+	ws, ok := writerStatsByKey[w]
+	writerStatsMutex.RUnlock()
+	if ok {
+		return ws
+	}
+	writerStatsMutex.Lock()
+	defer writerStatsMutex.Unlock()
+	ws, ok = writerStatsByKey[w]
+	if !ok {
+		if len(writerStatsByKey) >= maxWriterStats {
+			forgetLeastUsedWriter()
+		}
+		ws = &writerStats{latency: make([]uint64, len(LatencyBounds)+1), used: time.Now().UnixNano()}
+		writerStatsByKey[w] = ws
+		writerStatsNames[w] = writerIdentity(w)
+	}
+	return ws
+} //statsOf()
+
+//forgetLeastUsedWriter removes the stats of the writer that was not
+//updated for the longest time, called with writerStatsMutex locked
+func forgetLeastUsedWriter() {
+	var oldest io.Writer
+	oldestUsed := int64(0)
+	for w, ws := range writerStatsByKey {
+		if used := atomic.LoadInt64(&ws.used); oldest == nil || used < oldestUsed {
+			oldest, oldestUsed = w, used
+		}
+	}
+	delete(writerStatsByKey, oldest)
+	delete(writerStatsNames, oldest)
+}
+
+//timedWrite writes the record and updates the stats of the writer
+func timedWrite(w io.Writer, l ILogger, r Record, encoded []byte) (int, error) {
+	start := time.Now()
+	n, err := writeRecord(w, l, r, encoded)
+	if ws := statsOf(w); ws != nil {
+		ws.observe(time.Since(start), n, err)
+	}
+	return n, err
+}
+
+func (ws *writerStats) observe(latency time.Duration, n int, err error) {
+	ws.touch()
+	atomic.AddUint64(&ws.writes, 1)
+	atomic.AddUint64(&ws.bytes, uint64(n))
+	if err != nil {
+		atomic.AddUint64(&ws.errors, 1)
+	}
+	i := 0
+	for i < len(LatencyBounds) && latency > LatencyBounds[i] {
+		i++
+	}
+	atomic.AddUint64(&ws.latency[i], 1)
+}
+
+func (ws *writerStats) batch(size int) {
+	if ws == nil {
+		return
+	}
+	ws.touch()
+	atomic.AddUint64(&ws.batches, 1)
+	atomic.AddUint64(&ws.batchBytes, uint64(size))
+}
+
+func (ws *writerStats) retry() {
+	if ws == nil {
+		return
+	}
+	ws.touch()
+	atomic.AddUint64(&ws.retries, 1)
+}
+
+func (ws *writerStats) drop() {
+	if ws == nil {
+		return
+	}
+	ws.touch()
+	atomic.AddUint64(&ws.drops, 1)
+}
+
+func (ws *writerStats) touch() {
+	atomic.StoreInt64(&ws.used, time.Now().UnixNano())
+}
+
+func init() {
+	//publish writer stats so they appear under "log_sinks" in /debug/vars
+	expvar.Publish("log_sinks", expvar.Func(func() interface{} {
+		return SinkStats()
+	}))
+}
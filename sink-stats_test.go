@@ -0,0 +1,26 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSinkStatsForgetsLeastUsedWriters(t *testing.T) {
+	defer func(max int) { maxWriterStats = max }(maxWriterStats)
+	maxWriterStats = len(writerStatsByKey) + 2
+	used := &bytes.Buffer{}
+	l := Top().Temp("xtest")
+	l.WithWriter(used).Infof("one")
+	for i := 0; i < 10; i++ {
+		l.WithWriter(&bytes.Buffer{}).Infof("temporary")
+		l.WithWriter(used).Infof("two")
+	}
+	writerStatsMutex.RLock()
+	defer writerStatsMutex.RUnlock()
+	if n := len(writerStatsByKey); n > maxWriterStats {
+		t.Fatalf("stats of %d writers kept, want at most %d", n, maxWriterStats)
+	}
+	if ws, ok := writerStatsByKey[used]; !ok || ws.writes != 11 {
+		t.Fatalf("stats of the writer in use = %+v", ws)
+	}
+}
@@ -0,0 +1,101 @@
+package log
+
+import (
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//VSCodeLink is a CallerFormat.Link that opens the code in VS Code
+const VSCodeLink = "vscode://file{path}:{line}"
+
+//GoLandLink is a CallerFormat.Link that opens the code in GoLand
+const GoLandLink = "jetbrains://goland/navigate/reference?path={path}:{line}"
+
+//GitHubLink returns a CallerFormat.Link to the code on GitHub at the source
+//commit, e.g. GitHubLink("go-msvc/log")
+func GitHubLink(repo string) string {
+	return "https://github.com/" + repo + "/blob/{commit}/{rel}#L{line}"
+}
+
+var (
+	linkMutex    sync.Mutex
+	sourceCommit string
+	moduleRoots  = map[string]string{} //source dir -> module root
+)
+
+//SetSourceCommit sets the commit used in links to the code, the default
+//is the VCS revision in the build info, or else "HEAD"
+func SetSourceCommit(commit string) {
+	linkMutex.Lock()
+	defer linkMutex.Unlock()
+	sourceCommit = commit
+}
+
+func linkCommit() string {
+	linkMutex.Lock()
+	defer linkMutex.Unlock()
+	if sourceCommit == "" {
+		sourceCommit = "HEAD"
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range info.Settings {
+				if s.Key == "vcs.revision" && s.Value != "" {
+					sourceCommit = s.Value
+				}
+			}
+		}
+	}
+	return sourceCommit
+}
+
+//URL returns the link of the caller
+func (cf CallerFormat) URL(c Caller) string {
+	return strings.NewReplacer(
+		"{path}", linkPath(c.Path),
+		"{rel}", relativeSourcePath(c),
+		"{line}", strconv.Itoa(c.Line),
+		"{commit}", linkCommit(),
+	).Replace(cf.Link)
+}
+
+//relativeSourcePath returns the caller file path relative to its module root
+func relativeSourcePath(c Caller) string {
+	if c.Path == "" {
+		return strings.TrimPrefix(c.File, "/")
+	}
+	dir := path.Dir(c.Path)
+	linkMutex.Lock()
+	root, ok := moduleRoots[dir]
+	linkMutex.Unlock()
+	if !ok {
+		root = moduleRoot(c.Path, runtime.FuncForPC(c.PC))
+		linkMutex.Lock()
+		moduleRoots[dir] = root
+		linkMutex.Unlock()
+	}
+	if root == "" {
+		return strings.TrimPrefix(c.File, "/")
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(c.Path, root), "/")
+}
+
+//linkPath returns the full path starting with "/", also on Windows,
+//e.g. "/C:/src/app/main.go"
+func linkPath(p string) string {
+	if strings.HasPrefix(p, "/") {
+		return p
+	}
+	return "/" + p
+}
+
+//hyperlink writes text as a terminal hyperlink to url, or only the text
+//when escape sequences are not enabled
+func hyperlink(url, text string) string {
+	if !ColorEnabled() {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
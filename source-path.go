@@ -0,0 +1,150 @@
+package log
+
+import (
+	"go/build"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//source path trimming applied to Caller.File
+var (
+	sourceMutex    sync.RWMutex
+	sourcePrefixes []string
+	sourceTrimPath bool
+)
+
+//SetTrimPrefixes sets the directories removed from the start of Caller.File,
+//replacing any prefixes set before, e.g. SetTrimPrefixes("/home/me/src/app/")
+//the longest matching prefix is removed
+func SetTrimPrefixes(prefixes ...string) {
+	sourceMutex.Lock()
+	defer sourceMutex.Unlock()
+	sourcePrefixes = nil
+	addTrimPrefixes(prefixes...)
+}
+
+//AddTrimPrefixes adds directories to remove from the start of Caller.File
+func AddTrimPrefixes(prefixes ...string) {
+	sourceMutex.Lock()
+	defer sourceMutex.Unlock()
+	addTrimPrefixes(prefixes...)
+}
+
+func addTrimPrefixes(prefixes ...string) {
+	for _, p := range prefixes {
+		if p == "" {
+			continue
+		}
+		p = filepath.ToSlash(p)
+		if !strings.HasSuffix(p, "/") {
+			p += "/"
+		}
+		sourcePrefixes = append(sourcePrefixes, p)
+	}
+	//longest first so that the most specific prefix is removed
+	sort.Slice(sourcePrefixes, func(i, j int) bool {
+		return len(sourcePrefixes[i]) > len(sourcePrefixes[j])
+	})
+}
+
+//TrimModuleRoot adds the root directory of the module of the caller as
+//a trim prefix, so that source files are shown relative to the module,
+//e.g. "pkg/server/handler.go" rather than the absolute build path.
+//The root is found from the go.mod file or, when the source is not
+//available, from the package and module path in the build info.
+func TrimModuleRoot() {
+	pc, file, _, ok := runtime.Caller(1)
+	if !ok {
+		return
+	}
+	if root := moduleRoot(file, runtime.FuncForPC(pc)); root != "" {
+		AddTrimPrefixes(root)
+	}
+}
+
+func moduleRoot(file string, f *runtime.Func) string {
+	//look for go.mod in the directory of the file and its parents
+	for dir := path.Dir(file); dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+		if _, err := os.Stat(filepath.FromSlash(dir + "/go.mod")); err == nil {
+			return dir
+		}
+		if path.Dir(dir) == dir {
+			break
+		}
+	}
+
+	//else remove the package path within the module from the file directory
+	info, ok := debug.ReadBuildInfo()
+	if !ok || f == nil {
+		return ""
+	}
+	pkg := functionPackage(f.Name())
+	if pkg == "main" {
+		pkg = info.Path
+	}
+	if !strings.HasPrefix(pkg, info.Main.Path) {
+		return ""
+	}
+	rel := strings.TrimPrefix(pkg, info.Main.Path)
+	dir := path.Dir(file)
+	if !strings.HasSuffix(dir, rel) {
+		return ""
+	}
+	return strings.TrimSuffix(dir, rel)
+} //moduleRoot()
+
+//functionPackage returns the package path of a function name,
+//e.g. "github.com/x/app/pkg.Func" -> "github.com/x/app/pkg"
+func functionPackage(function string) string {
+	d, b := path.Split(function)
+	if i := strings.Index(b, "."); i >= 0 {
+		b = b[:i]
+	}
+	return d + b
+}
+
+//TrimGOPATH adds GOPATH/src, the module cache and GOROOT/src as trim prefixes,
+//so that dependencies and the standard library show as package paths
+func TrimGOPATH() {
+	prefixes := []string{}
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		prefixes = append(prefixes,
+			filepath.Join(gopath, "src"),
+			filepath.Join(gopath, "pkg", "mod"))
+	}
+	if goroot := runtime.GOROOT(); goroot != "" {
+		prefixes = append(prefixes, filepath.Join(goroot, "src"))
+	}
+	AddTrimPrefixes(prefixes...)
+}
+
+//SetTrimPath replaces the directory of Caller.File with the package path,
+//which is what building with -trimpath does, e.g.
+//"github.com/x/app/pkg/server/handler.go"
+//It takes precedence over trim prefixes.
+func SetTrimPath(enabled bool) {
+	sourceMutex.Lock()
+	defer sourceMutex.Unlock()
+	sourceTrimPath = enabled
+}
+
+//trimSourcePath applies the configured trimming to the file of a function
+func trimSourcePath(file, function string) string {
+	sourceMutex.RLock()
+	defer sourceMutex.RUnlock()
+	if sourceTrimPath && function != "" {
+		return functionPackage(function) + "/" + path.Base(file)
+	}
+	for _, p := range sourcePrefixes {
+		if strings.HasPrefix(file, p) {
+			return file[len(p):]
+		}
+	}
+	return file
+} //trimSourcePath()
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+)
+
+//SpanContext identifies the trace and span that a request is part of
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+//IsValid is true when both trace and span id are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+//TraceIDHex is the 32 character lowercase hex trace id
+func (sc SpanContext) TraceIDHex() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+//SpanIDHex is the 16 character lowercase hex span id
+func (sc SpanContext) SpanIDHex() string {
+	return hex.EncodeToString(sc.SpanID[:])
+}
+
+//SpanExtractor gets the span from a context where a tracing SDK stored it
+type SpanExtractor func(ctx context.Context) (SpanContext, bool)
+
+type spanContextKey struct{}
+
+var (
+	spanExtractorsMutex sync.Mutex
+	spanExtractors      []SpanExtractor
+)
+
+//ContextWithSpan returns a copy of ctx that carries the span
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+//RegisterSpanExtractor adds a function to find spans stored in the context
+//by a tracing SDK, so that this package need not depend on the SDK, e.g.
+//for OpenTelemetry:
+//	log.RegisterSpanExtractor(func(ctx context.Context) (log.SpanContext, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return log.SpanContext{TraceID: sc.TraceID(), SpanID: sc.SpanID()}, sc.IsValid()
+//	})
+func RegisterSpanExtractor(e SpanExtractor) {
+	if e == nil {
+		return
+	}
+	spanExtractorsMutex.Lock()
+	defer spanExtractorsMutex.Unlock()
+	spanExtractors = append(spanExtractors, e)
+}
+
+//SpanFromContext returns the span set with ContextWithSpan()
+//else the first one found by a registered SpanExtractor
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	if sc, ok := ctx.Value(spanContextKey{}).(SpanContext); ok && sc.IsValid() {
+		return sc, true
+	}
+	spanExtractorsMutex.Lock()
+	extractors := spanExtractors
+	spanExtractorsMutex.Unlock()
+	for _, e := range extractors {
+		if sc, ok := e(ctx); ok && sc.IsValid() {
+			return sc, true
+		}
+	}
+	return SpanContext{}, false
+} //SpanFromContext()
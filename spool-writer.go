@@ -0,0 +1,368 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+//NewSpoolWriter writes to w, but when a write to w fails the record and all
+//records after it are kept in segment files in dir (see NewSegmentWriter)
+//until w works again, so that a network partition does not lose logs. The
+//spool is retried every retry interval and drained in order. When the spool
+//exceeds maxBytes the oldest segment is removed and reported to the error
+//handler, so that a long outage does not fill the disk.
+//Records spooled when the process stops are sent after it starts again.
+//w must return write errors, e.g. the syslog TLS writer, or an HTTP writer,
+//which fails writes when its queue of batches is full.
+//The level and time of each record are spooled with it, so an IRecordWriter
+//such as the syslog writer gets them when the spool is drained, with the
+//top logger and without the fields of the record.
+//Records are drained in the background without blocking the loggers.
+func NewSpoolWriter(w io.Writer, dir string, maxBytes int64, retry time.Duration) (IBufferedWriter, error) {
+	segmentSize := maxBytes / 8
+	if segmentSize < 64<<10 {
+		segmentSize = 64 << 10
+	}
+	sw := &spoolWriter{
+		w:           w,
+		dir:         dir,
+		maxBytes:    maxBytes,
+		segmentSize: segmentSize,
+		stop:        make(chan struct{}),
+		kick:        make(chan struct{}, 1),
+	}
+	//open the spool left by a previous run
+	if err := sw.openSpool(); err != nil {
+		return nil, err
+	}
+	if retry <= 0 {
+		retry = 5 * time.Second
+	}
+	go sw.drainEvery(retry)
+	return sw, nil
+} //NewSpoolWriter()
+
+//NewAckedSpoolWriter is like NewSpoolWriter() but delivers at least once:
+//every record is written to the spool first and only removed from the spool
+//after w acknowledged it, i.e. w.Write() returned without error, which w must
+//only do once the remote confirmed the record. Records are sent in the
+//background. After a failure or restart some records may be sent again,
+//so use DeliveryIDs() on the encoder to let consumers remove duplicates.
+func NewAckedSpoolWriter(w io.Writer, dir string, maxBytes int64, retry time.Duration) (IBufferedWriter, error) {
+	bw, err := NewSpoolWriter(w, dir, maxBytes, retry)
+	if err != nil {
+		return nil, err
+	}
+	sw := bw.(*spoolWriter)
+	sw.mutex.Lock()
+	sw.acked = true
+	sw.mutex.Unlock()
+	sw.kickDrain()
+	return sw, nil
+} //NewAckedSpoolWriter()
+
+//spoolWriter implements IBufferedWriter and IRecordWriter
+//mutex protects the spool state used by writers, drainMutex serializes
+//drains so that records are sent without holding mutex
+type spoolWriter struct {
+	mutex       sync.Mutex
+	w           io.Writer
+	dir         string
+	maxBytes    int64
+	segmentSize int64
+	spool       ISegmentWriter
+	spooled     bool //true while there are records in the spool
+	acked       bool //true to spool all records before sending
+	stop        chan struct{}
+	kick        chan struct{}
+	closeOnce   sync.Once
+
+	drainMutex sync.Mutex
+	sentPath   string //segment being drained
+	sentOffset int64  //nr of bytes of sentPath already sent
+	failed     bool   //true when the last drain failed
+}
+
+//each spooled record starts with the kind, level and time (unix nanoseconds)
+const (
+	spoolHeaderSize = 10
+	spoolRaw        = 0 //output without a record
+	spoolRecord     = 1
+)
+
+func spoolFrame(kind byte, r Record, encoded []byte) []byte {
+	frame := make([]byte, spoolHeaderSize+len(encoded))
+	frame[0] = kind
+	frame[1] = byte(r.Level)
+	binary.BigEndian.PutUint64(frame[2:10], uint64(r.Time.UnixNano()))
+	copy(frame[spoolHeaderSize:], encoded)
+	return frame
+}
+
+//send writes a spooled frame to w
+func (sw *spoolWriter) send(frame []byte) error {
+	if len(frame) < spoolHeaderSize {
+		return fmt.Errorf("invalid spool frame of %d bytes", len(frame))
+	}
+	encoded := frame[spoolHeaderSize:]
+	if frame[0] == spoolRaw {
+		_, err := sw.w.Write(encoded)
+		return err
+	}
+	r := Record{
+		Time:  time.Unix(0, int64(binary.BigEndian.Uint64(frame[2:10]))),
+		Level: Level(int8(frame[1])),
+	}
+	_, err := writeRecord(sw.w, Top(), r, encoded)
+	return err
+}
+
+func (sw *spoolWriter) openSpool() error {
+	spool, err := NewSegmentWriter(sw.dir, sw.segmentSize)
+	if err != nil {
+		return fmt.Errorf("cannot open spool: %v", err)
+	}
+	sw.spool = spool
+	paths, err := spool.Segments()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			sw.spooled = true
+		}
+	}
+	return nil
+}
+
+//Write is used for output without a record
+func (sw *spoolWriter) Write(p []byte) (int, error) {
+	return sw.write(spoolRaw, Record{Time: time.Now()}, p, func() error {
+		_, err := sw.w.Write(p)
+		return err
+	})
+}
+
+func (sw *spoolWriter) WriteRecord(l ILogger, r Record, encoded []byte) (int, error) {
+	return sw.write(spoolRecord, r, encoded, func() error {
+		_, err := writeRecord(sw.w, l, r, encoded)
+		return err
+	})
+}
+
+//write sends the record directly when nothing is spooled, else spools it
+func (sw *spoolWriter) write(kind byte, r Record, encoded []byte, direct func() error) (int, error) {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	if !sw.spooled && !sw.acked {
+		if err := direct(); err == nil {
+			return len(encoded), nil
+		}
+	}
+	//keep order: once spooling, all records go to the spool until drained
+	sw.spooled = true
+	if _, err := sw.spool.Write(spoolFrame(kind, r, encoded)); err != nil {
+		return 0, fmt.Errorf("cannot spool record: %v", err)
+	}
+	sw.limit()
+	if sw.acked {
+		sw.kickDrain()
+	}
+	return len(encoded), nil
+} //spoolWriter.write()
+
+//kickDrain makes the background drain now rather than at the next retry
+func (sw *spoolWriter) kickDrain() {
+	select {
+	case sw.kick <- struct{}{}:
+	default:
+	}
+}
+
+//limit removes the oldest segments while the spool is too big
+func (sw *spoolWriter) limit() {
+	paths, err := sw.spool.Segments()
+	if err != nil {
+		return
+	}
+	total := int64(0)
+	sizes := make([]int64, len(paths))
+	for i, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+	//never remove the segment being written
+	for i := 0; i < len(paths)-1 && total > sw.maxBytes; i++ {
+		if err := os.Remove(paths[i]); err == nil {
+			handleError(fmt.Errorf("spool full, dropped %d bytes of records in %s", sizes[i], paths[i]))
+			total -= sizes[i]
+		}
+	}
+} //spoolWriter.limit()
+
+//drain writes the spooled records to w in order, continuing after the last
+//record that was sent, and removes each segment when all its records were
+//sent, until all are sent or a write failed. Only checking the spool state
+//is done while holding the writer lock.
+func (sw *spoolWriter) drain() (err error) {
+	sw.drainMutex.Lock()
+	defer sw.drainMutex.Unlock()
+	sw.mutex.Lock()
+	spooled := sw.spooled
+	sw.mutex.Unlock()
+	if !spooled {
+		return nil
+	}
+	//acked spools send every record by draining, which is only a retry
+	//when the previous drain failed
+	if !sw.acked || sw.failed {
+		statsOf(sw).retry()
+	}
+	defer func() { sw.failed = err != nil }()
+	for {
+		sw.mutex.Lock()
+		if !sw.spooled {
+			sw.mutex.Unlock()
+			return nil
+		}
+		paths, err := sw.spool.Segments()
+		sw.mutex.Unlock()
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return nil
+		}
+		path, last := paths[0], len(paths) == 1
+		if path != sw.sentPath {
+			sw.sentPath, sw.sentOffset = path, 0
+		}
+		if err := sw.drainSegment(path, last); err != nil {
+			return fmt.Errorf("cannot drain spool: %v", err)
+		}
+		if !last {
+			//the segment was removed when the spool was full
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if sw.drained(path) {
+			return nil
+		}
+		//more records were spooled while sending
+	}
+} //spoolWriter.drain()
+
+//drainSegment sends the records after sentOffset in the segment, and
+//stops without error at a frame that is still being written when it is
+//the last segment
+func (sw *spoolWriter) drainSegment(path string, last bool) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil //removed when the spool was full
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(sw.sentOffset, io.SeekStart); err != nil {
+		return err
+	}
+	var sendErr error
+	n, err := readFrames(bufio.NewReader(f), info.Size()-sw.sentOffset, func(frame []byte) error {
+		sendErr = sw.send(frame)
+		return sendErr
+	})
+	sw.sentOffset += n
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil && !last {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+} //spoolWriter.drainSegment()
+
+//drained is true when all records in the last segment were sent, then
+//new records are written directly to w again, except when acked.
+//The sent segment is removed so that it is not sent again after a restart,
+//but acked spools keep it until it is full to not reopen it for each record.
+func (sw *spoolWriter) drained(path string) bool {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	info, err := os.Stat(path)
+	if err != nil {
+		return true //try again at the next retry
+	}
+	if info.Size() != sw.sentOffset {
+		return false
+	}
+	sw.spooled = false
+	if sw.acked {
+		return true
+	}
+	if err := sw.spool.Close(); err != nil {
+		handleError(err)
+	}
+	if err := os.Remove(path); err != nil {
+		handleError(err)
+	}
+	sw.sentPath, sw.sentOffset = "", 0
+	if err := sw.openSpool(); err != nil {
+		handleError(err)
+	}
+	return true
+} //spoolWriter.drained()
+
+func (sw *spoolWriter) drainEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sw.stop:
+			return
+		case <-sw.kick:
+		case <-ticker.C:
+		}
+		//failures are expected while the remote is down,
+		//the next tick tries again
+		sw.drain()
+	}
+} //spoolWriter.drainEvery()
+
+//Flush tries to drain the spool, then flushes w and the spool
+func (sw *spoolWriter) Flush() error {
+	sw.drain()
+	if f, ok := sw.w.(IFlusher); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	return sw.spool.Flush()
+}
+
+//Close stops draining and closes the spool, records still in the spool are
+//sent when a spool writer is created on the same dir
+func (sw *spoolWriter) Close() error {
+	sw.closeOnce.Do(func() {
+		close(sw.stop)
+	})
+	sw.Flush()
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	return sw.spool.Close()
+}
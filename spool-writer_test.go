@@ -0,0 +1,182 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//remoteWriter is an IRecordWriter that fails while down and blocks while held
+type remoteWriter struct {
+	mutex   sync.Mutex
+	down    bool
+	hold    chan struct{}
+	records []Record
+	lines   []string
+}
+
+func (rw *remoteWriter) Write(p []byte) (int, error) {
+	return rw.WriteRecord(nil, Record{}, p)
+}
+
+func (rw *remoteWriter) WriteRecord(l ILogger, r Record, encoded []byte) (int, error) {
+	rw.mutex.Lock()
+	hold := rw.hold
+	rw.mutex.Unlock()
+	if hold != nil {
+		<-hold
+	}
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	if rw.down {
+		return 0, errors.New("down")
+	}
+	rw.records = append(rw.records, Record{Time: r.Time, Level: r.Level})
+	rw.lines = append(rw.lines, string(encoded))
+	return len(encoded), nil
+}
+
+func (rw *remoteWriter) set(down bool, hold chan struct{}) {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	rw.down = down
+	rw.hold = hold
+}
+
+func TestSpoolWriterKeepsLevelAndTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	remote := &remoteWriter{down: true}
+	sw, err := NewSpoolWriter(remote, dir, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sw.Close()
+	l := Top().Temp("spooltest").WithWriter(sw).WithEncoder(rawEncoder("x")).WithLevel(DebugLevel)
+
+	levels := []Level{DebugLevel, WarnLevel, ErrorLevel}
+	for _, level := range levels {
+		l.Log(level, "spooled")
+	}
+	if len(remote.records) != 0 {
+		t.Fatalf("sent %d records while down", len(remote.records))
+	}
+	remote.set(false, nil)
+	if err := sw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(remote.records) != len(levels) {
+		t.Fatalf("drained %d records, want %d", len(remote.records), len(levels))
+	}
+	for i, r := range remote.records {
+		if r.Level != levels[i] || time.Since(r.Time) > time.Minute {
+			t.Fatalf("record %d drained with level %s time %v", i, r.Level, r.Time)
+		}
+	}
+	//drained, so records are sent directly again
+	l.Infof("direct")
+	if len(remote.records) != len(levels)+1 {
+		t.Fatalf("sent %d records, want %d", len(remote.records), len(levels)+1)
+	}
+}
+
+func TestAckedSpoolWriterDrainDoesNotBlockWriters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	hold := make(chan struct{})
+	remote := &remoteWriter{hold: hold}
+	sw, err := NewAckedSpoolWriter(remote, dir, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sw.Close()
+	l := Top().Temp("spooltest").WithWriter(sw).WithEncoder(rawEncoder("x"))
+
+	//the first record starts a drain that blocks in the remote
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			l.Infof("record")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked while the spool was drained")
+	}
+	remote.set(false, nil)
+	close(hold)
+	if err := sw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	//each record sent once, the segment is not read again from the start
+	if len(remote.lines) != 100 {
+		t.Fatalf("sent %d records, want 100", len(remote.lines))
+	}
+	//no send failed, so nothing was retried
+	if n := atomic.LoadUint64(&statsOf(sw).retries); n != 0 {
+		t.Fatalf("%d retries", n)
+	}
+}
+
+func TestAckedSpoolWriterDeliversAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	remote := &remoteWriter{down: true}
+	sw, err := NewAckedSpoolWriter(remote, dir, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := Top().Temp("spooltest").WithWriter(sw).WithEncoder(DeliveryIDs(NewJSONEncoder()))
+	for i := 0; i < 3; i++ {
+		l.Infof("record %d", i)
+	}
+	//not acknowledged, so the records stay in the spool
+	sw.Close()
+
+	remote.set(false, nil)
+	sw, err = NewAckedSpoolWriter(remote, dir, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sw.Close()
+	if err := sw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	remote.mutex.Lock()
+	defer remote.mutex.Unlock()
+	ids := map[string]bool{}
+	for i, line := range remote.lines {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Fatalf("invalid JSON %s: %v", line, err)
+		}
+		if obj["message"] != fmt.Sprintf("record %d", i) {
+			t.Fatalf("record %d is %s", i, line)
+		}
+		id, _ := obj["delivery_id"].(string)
+		if id == "" || ids[id] {
+			t.Fatalf("delivery id %q missing or repeated in %s", id, line)
+		}
+		ids[id] = true
+	}
+	if len(ids) != 3 {
+		t.Fatalf("delivered %q, want 3 records", remote.lines)
+	}
+}
@@ -0,0 +1,177 @@
+package log
+
+import (
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+//LoggerStats describes the configuration and output of one logger
+type LoggerStats struct {
+	Level  Level             `json:"level"`
+	Counts map[string]uint64 `json:"counts"`
+	//Rate is the average nr of records per second over the last minute
+	Rate float64 `json:"rate"`
+	//SecretsRedacted is the nr of secrets masked by ScanSecrets
+	SecretsRedacted uint64 `json:"secrets_redacted,omitempty"`
+	//Last is the last record written and LastError the last record at
+	//ErrorLevel or higher, nil if there was none
+	Last      *RecordSummary `json:"last,omitempty"`
+	LastError *RecordSummary `json:"last_error,omitempty"`
+}
+
+//RecordSummary describes a record in the stats
+//Secrets in the message are masked with DefaultSecretPatterns, or with the
+//patterns of ScanSecrets when that is the encoder of the logger
+type RecordSummary struct {
+	Time    time.Time `json:"time"`
+	Level   Level     `json:"level"`
+	Message string    `json:"message"`
+}
+
+//Stats returns the stats of all loggers in the tree indexed by logger name
+func Stats() map[string]LoggerStats {
+	stats := map[string]LoggerStats{}
+	if tl, ok := top.(*logger); ok {
+		tl.walk(func(l *logger) {
+			name := l.Name()
+			if name == "" {
+				name = "/"
+			}
+			stats[name] = l.stats()
+		})
+	}
+	return stats
+} //Stats()
+
+func (l *logger) stats() LoggerStats {
+	s := LoggerStats{
+		Level:  l.level,
+		Counts: map[string]uint64{},
+	}
+	for i := range l.counts {
+		if n := atomic.LoadUint64(&l.counts[i]); n > 0 {
+			s.Counts[(Level(i) + _minLevel).String()] = n
+		}
+	}
+	s.Rate = l.rate.perSecond(time.Now())
+	s.SecretsRedacted = atomic.LoadUint64(&l.secretsRedacted)
+	l.lastMutex.Lock()
+	last, lastError := l.last, l.lastError
+	l.lastMutex.Unlock()
+	s.Last = l.summary(last)
+	s.LastError = l.summary(lastError)
+	return s
+} //logger.stats()
+
+//count one record emitted and remember it as the last activity
+//records of unnamed temp loggers (e.g. groups) count in their named parent
+func (l *logger) count(r Record) {
+	n := l.statsNode()
+	if r.Level >= _minLevel && r.Level <= _maxLevel {
+		atomic.AddUint64(&n.counts[r.Level-_minLevel], 1)
+	}
+	n.rate.add(time.Now())
+	//secrets are only masked when the summary is read from the stats
+	summary := RecordSummary{Time: r.Time, Level: r.Level, Message: r.Message}
+	n.lastMutex.Lock()
+	n.last = summary
+	if r.Level >= ErrorLevel {
+		n.lastError = summary
+	}
+	n.lastMutex.Unlock()
+}
+
+//summary returns the stored summary with secrets masked, nil if there was none
+func (l *logger) summary(stored RecordSummary) *RecordSummary {
+	if stored.Time.IsZero() {
+		return nil
+	}
+	stored.Message = redactSecrets(l.encoder, stored.Message)
+	return &stored
+}
+
+//statsNode returns the logger that counts the records of this logger
+func (l *logger) statsNode() *logger {
+	if l.name == "" {
+		if pl, ok := l.parent.(*logger); ok {
+			return pl.statsNode()
+		}
+	}
+	return l
+}
+
+//rateWindow is the period over which rates are calculated
+const rateWindow = 60
+
+//rateCounter counts records per second in a rolling one minute window
+//without locking, each slot holds the unix second in the high 32 bits
+//and the nr of records in that second in the low 32 bits
+type rateCounter struct {
+	slots [rateWindow]uint64
+}
+
+func (rc *rateCounter) add(now time.Time) {
+	sec := uint64(now.Unix())
+	slot := &rc.slots[sec%rateWindow]
+	for {
+		v := atomic.LoadUint64(slot)
+		next := sec<<32 | 1
+		if v>>32 == sec {
+			next = v + 1
+		}
+		if atomic.CompareAndSwapUint64(slot, v, next) {
+			return
+		}
+	}
+} //rateCounter.add()
+
+//perSecond is the average rate over the last minute
+func (rc *rateCounter) perSecond(now time.Time) float64 {
+	sec := now.Unix()
+	total := uint64(0)
+	for i := range rc.slots {
+		v := atomic.LoadUint64(&rc.slots[i])
+		if s := int64(v >> 32); s > sec-rateWindow && s <= sec {
+			total += v & 0xffffffff
+		}
+	}
+	return float64(total) / rateWindow
+}
+
+//RateText writes the rate of records per second written by the logger
+//over the last minute, e.g. "12.3/s", to see which logger floods the output
+func RateText(width int) ITextValue {
+	return rateText{width: width}
+}
+
+//============================================================================
+type rateText struct {
+	width int
+}
+
+func (c rateText) Text(l ILogger, r Record) string {
+	ll, ok := l.(*logger)
+	if !ok {
+		return textField(c.width, "")
+	}
+	return textField(c.width, fmt.Sprintf("%.1f/s", ll.statsNode().rate.perSecond(r.Time)))
+}
+
+//walk calls fn for this logger and all children in name order
+func (l *logger) walk(fn func(*logger)) {
+	fn(l)
+	for _, sub := range l.sortedSubs() {
+		if sl, ok := sub.(*logger); ok {
+			sl.walk(fn)
+		}
+	}
+} //logger.walk()
+
+func init() {
+	//publish logger stats so they appear under "log" in /debug/vars
+	expvar.Publish("log", expvar.Func(func() interface{} {
+		return Stats()
+	}))
+}
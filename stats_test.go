@@ -0,0 +1,56 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsMaskSecrets(t *testing.T) {
+	l := Top().Logger("statstest").WithWriter(bytes.NewBuffer(nil))
+	l.Errorf("login with Bearer abc.def failed")
+	s := Stats()["/statstest"]
+	for _, summary := range []*RecordSummary{s.Last, s.LastError} {
+		if summary == nil {
+			t.Fatalf("missing summary in %+v", s)
+		}
+		if strings.Contains(summary.Message, "abc.def") || !strings.Contains(summary.Message, SecretMask) {
+			t.Fatalf("secret not masked in %q", summary.Message)
+		}
+	}
+}
+
+func TestStatsCountWithoutAllocating(t *testing.T) {
+	l := Top().Temp("xcount").(*logger)
+	r := Record{Time: time.Now(), Level: ErrorLevel, Message: "failed"}
+	if n := testing.AllocsPerRun(100, func() { l.count(r) }); n != 0 {
+		t.Fatalf("%v allocations per record", n)
+	}
+	s := l.stats()
+	if s.Counts["error"] != 101 || s.Rate == 0 || s.LastError == nil || s.LastError.Message != "failed" {
+		t.Fatalf("stats %+v", s)
+	}
+}
+
+func TestAlertMasksSecrets(t *testing.T) {
+	alerted := make(chan Alert, 1)
+	remove := AddAlert(AlertRule{
+		Name:    "secrets",
+		Logger:  "alerttest",
+		Level:   ErrorLevel,
+		Window:  time.Minute,
+		OnAlert: func(a Alert) { alerted <- a },
+	})
+	defer remove()
+	l := Top().Logger("alerttest").WithWriter(bytes.NewBuffer(nil))
+	l.Errorf("login with Bearer abc.def failed")
+	select {
+	case a := <-alerted:
+		if strings.Contains(a.Message, "abc.def") {
+			t.Fatalf("secret not masked in %q", a.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("alert did not fire")
+	}
+}
@@ -0,0 +1,183 @@
+package log
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//SyslogConfig configures a syslog writer
+type SyslogConfig struct {
+	//Address is host:port of the syslog server, the port defaults to 6514
+	Address string
+	//Transport configures server certificate verification and client
+	//certificates, it is not used when TLS is set
+	Transport TransportOptions
+	//TLS configures server certificate verification (RootCAs, ServerName)
+	//and client certificates (Certificates) directly
+	TLS *tls.Config
+	//Facility defaults to 1 (user-level messages)
+	Facility int
+	//Hostname defaults to os.Hostname()
+	Hostname string
+	//AppName defaults to the program name
+	AppName string
+	//Severities default to SyslogSeverities()
+	Severities ISeverityMap
+	//DialTimeout defaults to 10s
+	DialTimeout time.Duration
+	//WriteTimeout limits the time to send one message, after which the
+	//connection is closed and made again, so that a stalled server does
+	//not block the loggers forever, default 10s
+	WriteTimeout time.Duration
+}
+
+//NewSyslogTLSWriter returns a writer that sends each record as an RFC 5424
+//message to a syslog server over TLS with the octet-counting framing of
+//RFC 5425. The connection is made on the first write and made again after
+//a write failed. The encoded record is the MSG part of the message, and
+//the logger level and time are used for PRI and TIMESTAMP.
+func NewSyslogTLSWriter(c SyslogConfig) (io.WriteCloser, error) {
+	if c.Address == "" {
+		return nil, fmt.Errorf("missing syslog address")
+	}
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		c.Address = net.JoinHostPort(c.Address, "6514")
+	}
+	if c.Facility == 0 {
+		c.Facility = 1
+	}
+	if c.Facility < 0 || c.Facility > 23 {
+		return nil, fmt.Errorf("invalid syslog facility %d", c.Facility)
+	}
+	if c.Hostname == "" {
+		c.Hostname, _ = os.Hostname()
+	}
+	if c.AppName == "" {
+		c.AppName = filepath.Base(os.Args[0])
+	}
+	if c.Severities == nil {
+		c.Severities = SyslogSeverities()
+	}
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = 10 * time.Second
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = 10 * time.Second
+	}
+	if c.TLS == nil {
+		tc, err := c.Transport.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		c.TLS = tc
+	}
+	if c.TLS.ServerName == "" {
+		host, _, _ := net.SplitHostPort(c.Address)
+		c.TLS = c.TLS.Clone()
+		c.TLS.ServerName = host
+	}
+	return &syslogWriter{config: c, pid: os.Getpid()}, nil
+} //NewSyslogTLSWriter()
+
+//syslogTimeFormat is RFC 3339 with at most 6 digits of fraction as
+//the TIMESTAMP of RFC 5424 allows
+const syslogTimeFormat = "2006-01-02T15:04:05.999999Z07:00"
+
+//syslogWriter implements io.WriteCloser and IRecordWriter
+type syslogWriter struct {
+	mutex  sync.Mutex
+	config SyslogConfig
+	pid    int
+	conn   net.Conn
+}
+
+//Write sends output without a record with the default severity
+func (sw *syslogWriter) Write(p []byte) (int, error) {
+	return sw.send(sw.config.Severities.Severity(Level(_maxLevel+1)), time.Now(), p)
+}
+
+func (sw *syslogWriter) WriteRecord(l ILogger, r Record, encoded []byte) (int, error) {
+	return sw.send(sw.config.Severities.Severity(r.Level), r.Time, encoded)
+}
+
+func (sw *syslogWriter) send(s Severity, t time.Time, p []byte) (int, error) {
+	msg, _ := trimNewline(p)
+	//<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	procID := strconv.Itoa(sw.pid)
+	if isDeterministic() {
+		procID = "-"
+	}
+	m := fmt.Sprintf("<%d>1 %s %s %s %s - - %s",
+		sw.config.Facility*8+s.Code,
+		t.Format(syslogTimeFormat),
+		syslogHeaderField(sw.config.Hostname, 255),
+		syslogHeaderField(sw.config.AppName, 48),
+		procID,
+		msg)
+	frame := []byte(fmt.Sprintf("%d %s", len(m), m))
+
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	//retry once on a new connection when the server closed the old one
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt > 0 {
+			statsOf(sw).retry()
+		}
+		if sw.conn == nil {
+			if err = sw.dial(); err != nil {
+				return 0, err
+			}
+		}
+		sw.conn.SetWriteDeadline(time.Now().Add(sw.config.WriteTimeout))
+		if _, err = sw.conn.Write(frame); err == nil {
+			return len(p), nil
+		}
+		//a partly written frame cannot be continued, so always reconnect
+		sw.conn.Close()
+		sw.conn = nil
+	}
+	return 0, err
+} //syslogWriter.send()
+
+func (sw *syslogWriter) dial() error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: sw.config.DialTimeout}, "tcp", sw.config.Address, sw.config.TLS)
+	if err != nil {
+		return fmt.Errorf("cannot connect to syslog %s: %v", sw.config.Address, err)
+	}
+	sw.conn = conn
+	return nil
+}
+
+func (sw *syslogWriter) Close() error {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	if sw.conn == nil {
+		return nil
+	}
+	err := sw.conn.Close()
+	sw.conn = nil
+	return err
+}
+
+//syslogHeaderField returns s limited to printable US-ASCII without spaces
+//and max length as RFC 5424 requires for header fields, or "-" if empty
+func syslogHeaderField(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if s[i] > 32 && s[i] < 127 {
+			b = append(b, s[i])
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
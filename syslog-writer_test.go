@@ -0,0 +1,85 @@
+package log
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogTLSWriter(t *testing.T) {
+	//use the certificate of a test TLS server, valid for "example.com"
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: srv.TLS.Certificates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	messages := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			//octet counting: "<len> <message>"
+			var n int
+			if _, err := fmt.Fscanf(r, "%d ", &n); err != nil {
+				return
+			}
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			messages <- string(msg)
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	w, err := NewSyslogTLSWriter(SyslogConfig{
+		Address:  ln.Addr().String(),
+		TLS:      &tls.Config{RootCAs: roots, ServerName: "example.com"},
+		Hostname: "host",
+		AppName:  "app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	tests := []struct {
+		level Level
+		time  time.Time
+		want  string
+	}{
+		{ErrorLevel, time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC), "<11>1 2024-01-02T03:04:05.123456Z host app "},
+		{InfoLevel, time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 2*3600)), "<14>1 2024-01-02T03:04:05+02:00 host app "},
+		{FatalLevel, time.Date(2024, 1, 2, 3, 4, 5, 100000000, time.UTC), "<9>1 2024-01-02T03:04:05.1Z host app "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			rw := w.(IRecordWriter)
+			if _, err := rw.WriteRecord(Top(), Record{Level: tt.level, Time: tt.time}, []byte("hello\n")); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case msg := <-messages:
+				if !strings.HasPrefix(msg, tt.want) || !strings.HasSuffix(msg, " - - hello") {
+					t.Fatalf("message %q, want prefix %q", msg, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no message received")
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package log
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"sync"
+)
+
+//NewTenantRouter returns a writer that writes each record to the writer
+//of the tenant named by the data value called field, so that the logs of
+//different customers are kept apart. Tenant writers are created with open
+//when first needed and at most maxOpen are kept, closing the least recently
+//used one (if it is an io.Closer) to make space. Records without a tenant,
+//or that failed to open a tenant writer, are written to fallback.
+//Tenant names are not passed to open when they could be used as a path
+//outside a directory, i.e. "", "." and ".." or names with a slash,
+//backslash or control character: those records are written to fallback
+//and reported (see SetErrorHandler()).
+//Records of different tenants are written concurrently, each tenant
+//writer is only used by one goroutine at a time.
+func NewTenantRouter(field string, maxOpen int, open func(tenant string) (io.Writer, error), fallback io.Writer) io.WriteCloser {
+	if maxOpen < 1 {
+		maxOpen = 1
+	}
+	return &tenantRouter{
+		field:    field,
+		maxOpen:  maxOpen,
+		open:     open,
+		fallback: fallback,
+		lru:      list.New(),
+		tenants:  map[string]*list.Element{},
+	}
+}
+
+//tenantRouter implements io.WriteCloser and IRecordWriter
+//the mutex protects lru and tenants, not the tenant writers
+type tenantRouter struct {
+	mutex    sync.Mutex
+	field    string
+	maxOpen  int
+	open     func(tenant string) (io.Writer, error)
+	fallback io.Writer
+	lru      *list.List //of *tenantWriter, most recently used in front
+	tenants  map[string]*list.Element
+}
+
+//tenantWriter is the writer of one tenant with its own lock, so that a
+//write to one tenant does not wait for writes to others
+type tenantWriter struct {
+	mutex  sync.Mutex
+	tenant string
+	writer io.Writer
+	closed bool
+}
+
+//Write is used for output without a record and writes to the fallback writer
+func (tr *tenantRouter) Write(p []byte) (int, error) {
+	return tr.fallback.Write(p)
+}
+
+func (tr *tenantRouter) WriteRecord(l ILogger, r Record, encoded []byte) (int, error) {
+	v, ok := recordData(l, r)[tr.field]
+	if !ok || v == nil {
+		return tr.fallback.Write(encoded)
+	}
+	tenant := fmt.Sprint(v)
+	if !validTenant(tenant) {
+		handleError(fmt.Errorf("invalid tenant %q", tenant))
+		return tr.fallback.Write(encoded)
+	}
+	for {
+		tw, err := tr.writer(tenant)
+		if err != nil {
+			handleError(fmt.Errorf("cannot open writer for tenant %q: %v", tenant, err))
+			return tr.fallback.Write(encoded)
+		}
+		if n, ok, err := tw.write(encoded); ok {
+			return n, err
+		}
+		//evicted before it was written, so open it again
+	}
+} //tenantRouter.WriteRecord()
+
+//validTenant is false for tenant names that cannot safely be used as a
+//file name in a directory
+func validTenant(tenant string) bool {
+	if tenant == "" || tenant == "." || tenant == ".." {
+		return false
+	}
+	for _, c := range tenant {
+		if c == '/' || c == '\\' || c < ' ' || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+//writer returns the writer of the tenant, opening it when needed and
+//closing the least recently used writers to make space
+func (tr *tenantRouter) writer(tenant string) (*tenantWriter, error) {
+	tr.mutex.Lock()
+	if e, ok := tr.tenants[tenant]; ok {
+		tr.lru.MoveToFront(e)
+		tr.mutex.Unlock()
+		return e.Value.(*tenantWriter), nil
+	}
+	w, err := tr.open(tenant)
+	if err != nil {
+		tr.mutex.Unlock()
+		return nil, err
+	}
+	evicted := []*tenantWriter{}
+	for tr.lru.Len() >= tr.maxOpen {
+		evicted = append(evicted, tr.remove(tr.lru.Back()))
+	}
+	tw := &tenantWriter{tenant: tenant, writer: w}
+	tr.tenants[tenant] = tr.lru.PushFront(tw)
+	tr.mutex.Unlock()
+
+	//close outside the router lock, waiting only for writes to those tenants
+	for _, e := range evicted {
+		if err := e.close(); err != nil {
+			handleError(fmt.Errorf("cannot close writer for tenant %q: %v", e.tenant, err))
+		}
+	}
+	return tw, nil
+} //tenantRouter.writer()
+
+//remove removes a tenant writer without closing it,
+//the caller must hold the mutex
+func (tr *tenantRouter) remove(e *list.Element) *tenantWriter {
+	tw := tr.lru.Remove(e).(*tenantWriter)
+	delete(tr.tenants, tw.tenant)
+	return tw
+}
+
+//writers returns the open tenant writers
+func (tr *tenantRouter) writers() []*tenantWriter {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	tws := make([]*tenantWriter, 0, tr.lru.Len())
+	for e := tr.lru.Front(); e != nil; e = e.Next() {
+		tws = append(tws, e.Value.(*tenantWriter))
+	}
+	return tws
+}
+
+//removeAll removes and returns all tenant writers without closing them
+func (tr *tenantRouter) removeAll() []*tenantWriter {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	tws := make([]*tenantWriter, 0, tr.lru.Len())
+	for tr.lru.Len() > 0 {
+		tws = append(tws, tr.remove(tr.lru.Back()))
+	}
+	return tws
+}
+
+//Flush flushes the tenant writers that buffer output
+func (tr *tenantRouter) Flush() error {
+	var err error
+	for _, tw := range tr.writers() {
+		if ferr := tw.flush(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+//Close closes all tenant writers, but not the fallback writer
+func (tr *tenantRouter) Close() error {
+	var err error
+	for _, tw := range tr.removeAll() {
+		if cerr := tw.close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+//write writes p unless the writer was closed, then ok is false
+func (tw *tenantWriter) write(p []byte) (n int, ok bool, err error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if tw.closed {
+		return 0, false, nil
+	}
+	n, err = tw.writer.Write(p)
+	return n, true, err
+}
+
+func (tw *tenantWriter) flush() error {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if f, ok := tw.writer.(IFlusher); ok && !tw.closed {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (tw *tenantWriter) close() error {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	if tw.closed {
+		return nil
+	}
+	tw.closed = true
+	if c, ok := tw.writer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
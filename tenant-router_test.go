@@ -0,0 +1,105 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestTenantRouterNames(t *testing.T) {
+	tests := []struct {
+		tenant interface{}
+		opened bool
+	}{
+		{"acme", true},
+		{42, true},
+		{"a.b", true},
+		{"../x", false},
+		{"a/b", false},
+		{`a\b`, false},
+		{"..", false},
+		{".", false},
+		{"", false},
+		{"a\nb", false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.tenant), func(t *testing.T) {
+			var opened []string
+			fallback := &lockedBuffer{}
+			w := NewTenantRouter("tenant", 2, func(tenant string) (io.Writer, error) {
+				opened = append(opened, tenant)
+				return &bytes.Buffer{}, nil
+			}, fallback)
+			SetErrorHandler(func(error) {})
+			defer SetErrorHandler(nil)
+			Top().Temp("tenanttest").WithWriter(w).With("tenant", tt.tenant).Infof("hello")
+			if tt.opened != (len(opened) == 1) || tt.opened == (fallback.String() != "") {
+				t.Fatalf("opened %q, fallback %q", opened, fallback.String())
+			}
+		})
+	}
+}
+
+//closeCheckWriter fails the test when written after it was closed
+type closeCheckWriter struct {
+	t      *testing.T
+	mutex  sync.Mutex
+	closed bool
+	lines  int
+}
+
+func (w *closeCheckWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		w.t.Error("write after close")
+	}
+	w.lines++
+	return len(p), nil
+}
+
+func (w *closeCheckWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closed = true
+	return nil
+}
+
+func TestTenantRouterEviction(t *testing.T) {
+	var mutex sync.Mutex
+	writers := []*closeCheckWriter{}
+	w := NewTenantRouter("tenant", 2, func(tenant string) (io.Writer, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		cw := &closeCheckWriter{t: t}
+		writers = append(writers, cw)
+		return cw, nil
+	}, io.Discard)
+	l := Top().Temp("tenanttest").WithWriter(w)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.With("tenant", (g+i)%5).Infof("record %d", i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	w.Close()
+
+	lines := 0
+	for _, cw := range writers {
+		if !cw.closed {
+			t.Fatal("writer not closed")
+		}
+		lines += cw.lines
+	}
+	if lines != 800 {
+		t.Fatalf("wrote %d records, want 800", lines)
+	}
+}
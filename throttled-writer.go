@@ -0,0 +1,94 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+//NewThrottledWriter limits the rate of writes to w to bytesPerSecond, with
+//bursts of up to burst bytes, so that shipping logs during an error storm
+//cannot starve the service's own network traffic. Writes block until the
+//bytes are allowed. Wrap the writer of a remote sink, e.g. the HTTP writer,
+//and put a spool or buffer in front when callers must not block. Records
+//are passed on with WriteRecord when w implements IRecordWriter.
+func NewThrottledWriter(w io.Writer, bytesPerSecond, burst int) io.WriteCloser {
+	if burst < bytesPerSecond/10 {
+		burst = bytesPerSecond / 10
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &throttledWriter{
+		w:      w,
+		rate:   float64(bytesPerSecond),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+//throttledWriter implements io.WriteCloser with a token bucket
+type throttledWriter struct {
+	mutex  sync.Mutex
+	w      io.Writer
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+//Write is used for output without a record
+func (tw *throttledWriter) Write(p []byte) (int, error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	tw.wait(len(p))
+	return tw.w.Write(p)
+}
+
+func (tw *throttledWriter) WriteRecord(l ILogger, r Record, encoded []byte) (int, error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	tw.wait(len(encoded))
+	return writeRecord(tw.w, l, r, encoded)
+}
+
+//wait blocks until size bytes may be written and takes them from the
+//bucket, called with the mutex held so that writes stay in order
+func (tw *throttledWriter) wait(size int) {
+	now := time.Now()
+	tw.tokens += now.Sub(tw.last).Seconds() * tw.rate
+	if tw.tokens > tw.burst {
+		tw.tokens = tw.burst
+	}
+	tw.last = now
+	//a write bigger than the burst is allowed when the bucket is full,
+	//leaving the bucket in debt so that the average rate is kept
+	need := float64(size)
+	if need > tw.burst {
+		need = tw.burst
+	}
+	if tw.tokens < need {
+		wait := time.Duration((need - tw.tokens) / tw.rate * float64(time.Second))
+		time.Sleep(wait)
+		tw.tokens = need
+		tw.last = time.Now()
+	}
+	tw.tokens -= float64(size)
+} //throttledWriter.wait()
+
+//Flush flushes w if it buffers output
+func (tw *throttledWriter) Flush() error {
+	if f, ok := tw.w.(IFlusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+//Close closes w if it can be closed
+func (tw *throttledWriter) Close() error {
+	if c, ok := tw.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottledWriter(t *testing.T) {
+	remote := &remoteWriter{}
+	tw := NewThrottledWriter(remote, 1000, 100)
+	l := Top().Temp("xtest").WithWriter(tw)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		tw.Write(make([]byte, 100))
+	}
+	//the burst is free, the next 200 bytes take 200ms at 1000 bytes/s
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Fatalf("300 bytes written in %v", d)
+	}
+	l.Errorf("throttled")
+	if n := len(remote.records); n != 4 || remote.records[3].Level != ErrorLevel {
+		t.Fatalf("records %+v, want the error passed on as a record", remote.records)
+	}
+}
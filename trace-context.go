@@ -0,0 +1,181 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//W3C trace context headers
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+//TraceContext is the trace context of a request
+//When received (see ExtractTraceContext()) SpanID is the span of the
+//caller, written as "parent_id". In the context of a request handled by
+//HTTPMiddleware() it is the span of this server, a child of that span.
+type TraceContext struct {
+	SpanContext
+	//ParentSpanID is the span that SpanID is a child of, zero if not known
+	ParentSpanID [8]byte
+	//Flags are the trace flags, see Sampled()
+	Flags byte
+	//Debug is the B3 debug flag, which also sets sampled
+	Debug bool
+	//State is the vendor specific tracestate, "" if none
+	State string
+}
+
+//Child returns the trace context of a new span in the same trace with
+//this span as its parent, e.g. for the server span of a received request
+func (tc TraceContext) Child() TraceContext {
+	child := tc
+	child.ParentSpanID = tc.SpanID
+	child.SpanID = newSpanID()
+	return child
+}
+
+//newSpanID returns a random non-zero span id
+func newSpanID() [8]byte {
+	var id [8]byte
+	for id == [8]byte{} {
+		if _, err := rand.Read(id[:]); err != nil {
+			//crypto/rand does not fail on supported systems
+			panic(fmt.Sprintf("cannot generate span id: %v", err))
+		}
+	}
+	return id
+}
+
+//Sampled is true when the caller records the trace
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&0x01 != 0
+}
+
+//TraceParent formats the W3C traceparent header value, e.g.
+//"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func (tc TraceContext) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", tc.TraceIDHex(), tc.SpanIDHex(), tc.Flags)
+}
+
+//ParseTraceParent parses a W3C traceparent header value
+//"<version>-<trace-id>-<parent-id>-<flags>", allowing more fields
+//after the flags for versions after 00 as the spec requires
+func ParseTraceParent(s string) (TraceContext, error) {
+	tc := TraceContext{}
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return tc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return tc, fmt.Errorf("invalid traceparent version in %q", s)
+	}
+	if _, err := hexByte(parts[0]); err != nil {
+		return tc, fmt.Errorf("invalid traceparent version in %q", s)
+	}
+	if !isLowerHex(parts[1]) || !isLowerHex(parts[2]) {
+		return tc, fmt.Errorf("invalid traceparent ids in %q", s)
+	}
+	hex.Decode(tc.TraceID[:], []byte(parts[1]))
+	hex.Decode(tc.SpanID[:], []byte(parts[2]))
+	if !tc.IsValid() {
+		return tc, fmt.Errorf("invalid traceparent ids in %q", s)
+	}
+	flags, err := hexByte(parts[3])
+	if err != nil {
+		return tc, fmt.Errorf("invalid traceparent flags in %q", s)
+	}
+	tc.Flags = flags
+	return tc, nil
+} //ParseTraceParent()
+
+//ExtractTraceContext gets the trace context from the W3C trace context
+//headers, else from the Zipkin B3 single or multi headers, returning false
+//when there is none or it is not valid
+func ExtractTraceContext(h http.Header) (TraceContext, bool) {
+	parents := h[http.CanonicalHeaderKey(TraceParentHeader)]
+	if len(parents) == 0 {
+		return extractB3(h)
+	}
+	if len(parents) != 1 {
+		//the spec requires ignoring multiple traceparent headers
+		return TraceContext{}, false
+	}
+	tc, err := ParseTraceParent(parents[0])
+	if err != nil {
+		return TraceContext{}, false
+	}
+	tc.State = strings.Join(h[http.CanonicalHeaderKey(TraceStateHeader)], ",")
+	return tc, true
+} //ExtractTraceContext()
+
+//Propagation selects the headers that NewRoundTripper() sets
+type Propagation int
+
+//Propagation formats, combined with |, e.g. PropagateW3C | PropagateB3Multi
+const (
+	//PropagateW3C sets the traceparent and tracestate headers
+	PropagateW3C Propagation = 1 << iota
+	//PropagateB3Single sets the b3 header
+	PropagateB3Single
+	//PropagateB3Multi sets the X-B3-TraceId, X-B3-SpanId and X-B3-Sampled headers
+	PropagateB3Multi
+)
+
+//Inject sets the trace context headers in the formats of p
+func (tc TraceContext) Inject(h http.Header, p Propagation) {
+	if p&PropagateW3C != 0 {
+		h.Set(TraceParentHeader, tc.TraceParent())
+		if tc.State != "" {
+			h.Set(TraceStateHeader, tc.State)
+		}
+	}
+	if p&PropagateB3Single != 0 {
+		h.Set(B3Header, tc.B3())
+	}
+	if p&PropagateB3Multi != 0 {
+		tc.injectB3Multi(h)
+	}
+} //TraceContext.Inject()
+
+//WithTraceContext returns a temp logger with data "trace_id" and "parent_id"
+//from the trace context headers of the request (see ExtractTraceContext()),
+//without needing a tracing SDK, or l unchanged if the request has no valid
+//trace context
+func WithTraceContext(l ILogger, r *http.Request) ILogger {
+	tc, ok := ExtractTraceContext(r.Header)
+	if !ok {
+		return l
+	}
+	ll, ok := l.(*logger)
+	if !ok {
+		return l
+	}
+	sub := ll.anon(ll.group)
+	sub.data["trace_id"] = tc.TraceIDHex()
+	sub.data["parent_id"] = tc.SpanIDHex()
+	return sub
+} //WithTraceContext()
+
+func hexByte(s string) (byte, error) {
+	if !isLowerHex(s) {
+		return 0, fmt.Errorf("invalid hex %q", s)
+	}
+	b := []byte{0}
+	_, err := hex.Decode(b, []byte(s))
+	return b[0], err
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+	testParent  = "05e3ac9a4f6e3b90"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		in      string
+		sampled bool
+		wantErr bool
+	}{
+		{"00-" + testTraceID + "-" + testSpanID + "-01", true, false},
+		{" 00-" + testTraceID + "-" + testSpanID + "-00 ", false, false},
+		{"01-" + testTraceID + "-" + testSpanID + "-01-future", true, false},
+		{"00-" + testTraceID + "-" + testSpanID + "-01-extra", false, true},
+		{"ff-" + testTraceID + "-" + testSpanID + "-01", false, true},
+		{"00-" + "4BF92F3577B34DA6A3CE929D0E0E4736" + "-" + testSpanID + "-01", false, true},
+		{"00-00000000000000000000000000000000-" + testSpanID + "-01", false, true},
+		{"00-" + testTraceID + "-0000000000000000-01", false, true},
+		{"00-" + testTraceID + "-" + testSpanID + "-1", false, true},
+		{"00-" + testTraceID + "-" + testSpanID + "-zz", false, true},
+		{"", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			tc, err := ParseTraceParent(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if tc.TraceIDHex() != testTraceID || tc.SpanIDHex() != testSpanID || tc.Sampled() != tt.sampled {
+				t.Fatalf("parsed %+v", tc)
+			}
+		})
+	}
+}
+
+//TestTraceContextPropagation checks that the server and each outbound
+//request are new spans in the trace of the caller
+func TestTraceContextPropagation(t *testing.T) {
+	var outbound http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header
+	}))
+	defer backend.Close()
+
+	l := Top().Temp("tracetest").WithWriter(&lockedBuffer{})
+	client := &http.Client{Transport: NewRoundTripper(l, nil, PropagateW3C|PropagateB3Multi)}
+	var server TraceContext
+	handler := HTTPMiddleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server, _ = TraceContextFromContext(r.Context())
+		req, _ := http.NewRequest("GET", backend.URL, nil)
+		if res, err := client.Do(req.WithContext(r.Context())); err == nil {
+			res.Body.Close()
+		}
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(B3Header, testTraceID+"-"+testSpanID+"-d")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if server.TraceIDHex() != testTraceID || server.SpanIDHex() == testSpanID {
+		t.Fatalf("server span %s reuses the caller span", server.SpanIDHex())
+	}
+	client1, ok := ExtractTraceContext(outbound)
+	if !ok || client1.TraceID != server.TraceID {
+		t.Fatalf("outbound headers %v", outbound)
+	}
+	if client1.SpanID == server.SpanID || client1.SpanIDHex() == testSpanID {
+		t.Fatalf("outbound span %s reuses an incoming span", client1.SpanIDHex())
+	}
+	if outbound.Get(B3ParentSpanIDHeader) != server.SpanIDHex() || outbound.Get(B3FlagsHeader) != "1" {
+		t.Fatalf("outbound parent or debug flag missing in %v", outbound)
+	}
+}
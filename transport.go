@@ -0,0 +1,70 @@
+package log
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+//TransportOptions secure the connection of network sinks to collectors,
+//so that the same settings can be used for any sink
+type TransportOptions struct {
+	//CAFile is a PEM bundle of CAs to verify the server, default is the system roots
+	CAFile string
+	//CertFile and KeyFile are the PEM client certificate and key for mutual TLS
+	CertFile string
+	KeyFile  string
+	//ServerName overrides the name used for SNI and to verify the server certificate
+	ServerName string
+	//InsecureSkipVerify disables server certificate verification, only for testing
+	InsecureSkipVerify bool
+
+	//BearerToken is sent as "Authorization: Bearer <token>" by HTTP sinks
+	BearerToken string
+	//Username and Password are sent as basic auth by HTTP sinks when Username is set
+	Username string
+	Password string
+	//Headers are added to each request by HTTP sinks
+	Headers map[string]string
+}
+
+//TLSConfig returns the TLS config described by the options
+func (o TransportOptions) TLSConfig() (*tls.Config, error) {
+	c := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CAFile != "" {
+		pem, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA file: %v", err)
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in CA file %s", o.CAFile)
+		}
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %v", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+} //TransportOptions.TLSConfig()
+
+//SetHeaders sets the auth and other headers on an HTTP request
+func (o TransportOptions) SetHeaders(req *http.Request) {
+	for n, v := range o.Headers {
+		req.Header.Set(n, v)
+	}
+	if o.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+o.BearerToken)
+	} else if o.Username != "" {
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(o.Username+":"+o.Password)))
+	}
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransportOptionsSetHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		options TransportOptions
+		want    map[string]string
+	}{
+		{"none", TransportOptions{}, map[string]string{"Authorization": ""}},
+		{"bearer", TransportOptions{BearerToken: "t0k"}, map[string]string{"Authorization": "Bearer t0k"}},
+		{"basic", TransportOptions{Username: "u", Password: "p"}, map[string]string{"Authorization": "Basic dTpw"}},
+		{"bearer before basic", TransportOptions{BearerToken: "t0k", Username: "u"}, map[string]string{"Authorization": "Bearer t0k"}},
+		{"headers", TransportOptions{Headers: map[string]string{"X-Tenant": "a"}}, map[string]string{"X-Tenant": "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "http://localhost/", nil)
+			tt.options.SetHeaders(req)
+			for n, v := range tt.want {
+				if got := req.Header.Get(n); got != v {
+					t.Fatalf("%s = %q, want %q", n, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestTransportOptionsTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	notPEM := filepath.Join(dir, "ca.txt")
+	ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600)
+	tests := []struct {
+		name    string
+		options TransportOptions
+		wantErr string
+	}{
+		{"default", TransportOptions{ServerName: "logs.example.com"}, ""},
+		{"missing CA file", TransportOptions{CAFile: filepath.Join(dir, "missing.pem")}, "cannot read CA file"},
+		{"CA file without certificates", TransportOptions{CAFile: notPEM}, "no certificates"},
+		{"missing client key", TransportOptions{CertFile: notPEM}, "cannot load client certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.options.TLSConfig()
+			if tt.wantErr == "" {
+				if err != nil || c.ServerName != tt.options.ServerName {
+					t.Fatalf("config %+v, err %v", c, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
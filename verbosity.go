@@ -0,0 +1,59 @@
+package log
+
+import "strconv"
+
+//SetVerbosity sets the level of the top logger (and so all loggers)
+//from a count of -v flags: 0=warn, 1=info, 2=debug, 3 or more=trace
+func SetVerbosity(n int) {
+	switch {
+	case n <= 0:
+		top.SetLevel(WarnLevel)
+	case n == 1:
+		top.SetLevel(InfoLevel)
+	case n == 2:
+		top.SetLevel(DebugLevel)
+	default:
+		top.SetLevel(TraceLevel)
+	}
+} //SetVerbosity()
+
+//Quiet sets the level of the top logger (and so all loggers) to only log errors
+func Quiet() {
+	top.SetLevel(ErrorLevel)
+}
+
+//Verbosity is a flag.Value that counts repeated boolean flags and sets
+//the verbosity each time it is incremented, e.g.
+//	flag.Var(new(log.Verbosity), "v", "verbose, repeat for more")
+//then -v -v sets debug level. A value may also be specified with -v=3,
+//and -v=false resets it to 0
+type Verbosity int
+
+//String is the count
+func (v *Verbosity) String() string {
+	if v == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*v))
+}
+
+//Set increments the count for "true", resets it for "false" or sets a number
+func (v *Verbosity) Set(s string) error {
+	switch s {
+	case "true":
+		*v++
+	case "false":
+		*v = 0
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*v = Verbosity(n)
+	}
+	SetVerbosity(int(*v))
+	return nil
+} //Verbosity.Set()
+
+//IsBoolFlag allows the flag to be used without a value
+func (v *Verbosity) IsBoolFlag() bool { return true }
@@ -0,0 +1,32 @@
+package log
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestVerbosityFlag(t *testing.T) {
+	defer top.SetLevel(top.Level())
+	tests := []struct {
+		args []string
+		want Verbosity
+	}{
+		{[]string{}, 0},
+		{[]string{"-v"}, 1},
+		{[]string{"-v", "-v"}, 2},
+		{[]string{"-v=3"}, 3},
+		{[]string{"-v", "-v=false"}, 0},
+		{[]string{"-v=true"}, 1},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		var v Verbosity
+		fs.Var(&v, "v", "verbose")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		if v != tt.want {
+			t.Fatalf("%v gave %d, want %d", tt.args, v, tt.want)
+		}
+	}
+}